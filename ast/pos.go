@@ -0,0 +1,305 @@
+package ast
+
+import (
+	"unicode/utf8"
+
+	"github.com/blockchain-labs-org/solzaemon/token"
+)
+
+// textEnd returns the position just after text starting at pos.
+func textEnd(pos token.Pos, text string) token.Pos {
+	return pos + token.Pos(utf8.RuneCountInString(text))
+}
+
+// ----------------------------------------------------------------------------
+// Directives and declarations
+
+// Pos and End of a Program span its units; both are NoPos for an empty
+// file.
+func (x *Program) Pos() token.Pos {
+	if len(x.Units) > 0 {
+		return x.Units[0].Pos()
+	}
+	return token.NoPos
+}
+
+func (x *Program) End() token.Pos {
+	if n := len(x.Units); n > 0 {
+		return x.Units[n-1].End()
+	}
+	return token.NoPos
+}
+
+func (x *PragmaDirective) Pos() token.Pos { return x.Pragma }
+func (x *PragmaDirective) End() token.Pos { return x.Semicolon + 1 }
+
+func (x *ImportDirective) Pos() token.Pos { return x.Import }
+func (x *ImportDirective) End() token.Pos { return x.Semicolon + 1 }
+
+func (x *ImportSymbol) Pos() token.Pos { return x.Name.Pos() }
+func (x *ImportSymbol) End() token.Pos {
+	if x.Alias != nil {
+		return x.Alias.End()
+	}
+	return x.Name.End()
+}
+
+func (x *ContractPart) Pos() token.Pos { return x.Contract }
+func (x *ContractPart) End() token.Pos { return x.Rbrace + 1 }
+
+func (x *StateVariableDeclaration) Pos() token.Pos { return x.Typ.Pos() }
+func (x *StateVariableDeclaration) End() token.Pos { return x.Semicolon + 1 }
+
+func (x *FunctionDefinition) Pos() token.Pos { return x.Function }
+func (x *FunctionDefinition) End() token.Pos {
+	if x.Body != nil {
+		return x.Body.End()
+	}
+	return x.Semicolon + 1
+}
+
+func (x *OverrideSpecifier) Pos() token.Pos { return x.Override }
+func (x *OverrideSpecifier) End() token.Pos {
+	if x.Rparen.IsValid() {
+		return x.Rparen + 1
+	}
+	return textEnd(x.Override, "override")
+}
+
+func (x *UserDefinedValueTypeDefinition) Pos() token.Pos { return x.Type }
+func (x *UserDefinedValueTypeDefinition) End() token.Pos { return x.Semicolon + 1 }
+
+func (x *ModifierDefinition) Pos() token.Pos { return x.Modifier }
+func (x *ModifierDefinition) End() token.Pos {
+	if x.Body != nil {
+		return x.Body.End()
+	}
+	return x.Semicolon + 1
+}
+
+func (x *EventDefinition) Pos() token.Pos { return x.Event }
+func (x *EventDefinition) End() token.Pos { return x.Semicolon + 1 }
+
+func (x *ErrorDefinition) Pos() token.Pos { return x.Error }
+func (x *ErrorDefinition) End() token.Pos { return x.Semicolon + 1 }
+
+func (x *StructDefinition) Pos() token.Pos { return x.Struct }
+func (x *StructDefinition) End() token.Pos { return x.Rbrace + 1 }
+
+func (x *EnumDefinition) Pos() token.Pos { return x.Enum }
+func (x *EnumDefinition) End() token.Pos { return x.Rbrace + 1 }
+
+func (x *UsingDirective) Pos() token.Pos { return x.Using }
+func (x *UsingDirective) End() token.Pos { return x.Semicolon + 1 }
+
+func (x *ParameterList) Pos() token.Pos { return x.Lparen }
+func (x *ParameterList) End() token.Pos { return x.Rparen + 1 }
+
+func (x *Parameter) Pos() token.Pos { return x.Typ.Pos() }
+func (x *Parameter) End() token.Pos {
+	switch {
+	case x.Name != nil:
+		return x.Name.End()
+	case x.Location != nil:
+		return x.Location.End()
+	case x.Indexed.IsValid():
+		return textEnd(x.Indexed, "indexed")
+	}
+	return x.Typ.End()
+}
+
+// ----------------------------------------------------------------------------
+// Type names
+
+func (x *ElementaryType) Pos() token.Pos { return x.NamePos }
+func (x *ElementaryType) End() token.Pos {
+	if x.Payable.IsValid() {
+		return textEnd(x.Payable, "payable")
+	}
+	return textEnd(x.NamePos, x.Name)
+}
+
+func (x *UserDefinedType) Pos() token.Pos { return x.Path[0].Pos() }
+func (x *UserDefinedType) End() token.Pos { return x.Path[len(x.Path)-1].End() }
+
+func (x *Mapping) Pos() token.Pos { return x.Mapping }
+func (x *Mapping) End() token.Pos { return x.Rparen + 1 }
+
+func (x *ArrayType) Pos() token.Pos { return x.Elt.Pos() }
+func (x *ArrayType) End() token.Pos { return x.Rbrack + 1 }
+
+func (x *FunctionType) Pos() token.Pos { return x.Function }
+func (x *FunctionType) End() token.Pos {
+	if x.Returns != nil {
+		return x.Returns.End()
+	}
+	end := x.Params.End()
+	if x.VisibilityPos > end {
+		end = textEnd(x.VisibilityPos, x.Visibility)
+	}
+	if x.MutabilityPos > end {
+		end = textEnd(x.MutabilityPos, x.Mutability)
+	}
+	return end
+}
+
+// ----------------------------------------------------------------------------
+// Expressions
+
+func (x *BadExpr) Pos() token.Pos { return x.From }
+func (x *BadExpr) End() token.Pos { return x.To }
+
+func (x *Ident) Pos() token.Pos { return x.NamePos }
+func (x *Ident) End() token.Pos { return textEnd(x.NamePos, x.Name) }
+
+func (x *BinaryExpr) Pos() token.Pos { return x.X.Pos() }
+func (x *BinaryExpr) End() token.Pos { return x.Y.End() }
+
+func (x *UnaryExpr) Pos() token.Pos {
+	if x.Postfix {
+		return x.X.Pos()
+	}
+	return x.OpPos
+}
+
+func (x *UnaryExpr) End() token.Pos {
+	if x.Postfix {
+		return x.OpPos + 2 // ++ or --
+	}
+	return x.X.End()
+}
+
+func (x *ConditionalExpr) Pos() token.Pos { return x.Cond.Pos() }
+func (x *ConditionalExpr) End() token.Pos { return x.Else.End() }
+
+func (x *TupleExpr) Pos() token.Pos { return x.Lparen }
+func (x *TupleExpr) End() token.Pos { return x.Rparen + 1 }
+
+func (x *IndexExpr) Pos() token.Pos { return x.X.Pos() }
+func (x *IndexExpr) End() token.Pos { return x.Rbrack + 1 }
+
+func (x *SelectorExpr) Pos() token.Pos { return x.X.Pos() }
+func (x *SelectorExpr) End() token.Pos { return x.Sel.End() }
+
+func (x *ParenExpr) Pos() token.Pos { return x.Lparen }
+func (x *ParenExpr) End() token.Pos { return x.Rparen + 1 }
+
+func (x *BasicLit) Pos() token.Pos { return x.ValuePos }
+func (x *BasicLit) End() token.Pos {
+	if x.Unit != nil {
+		return x.Unit.End()
+	}
+	return textEnd(x.ValuePos, x.Value)
+}
+
+// Pos and End of a CallExpr span only Fun for a modifier invocation
+// without arguments.
+func (x *CallExpr) Pos() token.Pos { return x.Fun.Pos() }
+func (x *CallExpr) End() token.Pos {
+	if x.Rparen.IsValid() {
+		return x.Rparen + 1
+	}
+	return x.Fun.End()
+}
+
+func (x *NamedArgList) Pos() token.Pos { return x.Lbrace }
+func (x *NamedArgList) End() token.Pos { return x.Rbrace + 1 }
+
+func (x *NamedArg) Pos() token.Pos { return x.Name.Pos() }
+func (x *NamedArg) End() token.Pos { return x.Value.End() }
+
+func (x *CallOptions) Pos() token.Pos { return x.X.Pos() }
+func (x *CallOptions) End() token.Pos { return x.Options.End() }
+
+func (x *IndexRangeExpr) Pos() token.Pos { return x.X.Pos() }
+func (x *IndexRangeExpr) End() token.Pos { return x.Rbrack + 1 }
+
+func (x *NewExpr) Pos() token.Pos { return x.New }
+func (x *NewExpr) End() token.Pos { return x.Typ.End() }
+
+func (x *MetaTypeExpr) Pos() token.Pos { return x.Type }
+func (x *MetaTypeExpr) End() token.Pos { return x.Rparen + 1 }
+
+func (x *ArrayLit) Pos() token.Pos { return x.Lbrack }
+func (x *ArrayLit) End() token.Pos { return x.Rbrack + 1 }
+
+// ----------------------------------------------------------------------------
+// Statements
+
+func (s *BadStmt) Pos() token.Pos { return s.From }
+func (s *BadStmt) End() token.Pos { return s.To }
+
+func (s *EmptyStmt) Pos() token.Pos { return s.Semicolon }
+func (s *EmptyStmt) End() token.Pos { return s.Semicolon + 1 }
+
+func (s *ExprStmt) Pos() token.Pos { return s.X.Pos() }
+func (s *ExprStmt) End() token.Pos { return s.Semicolon + 1 }
+
+func (s *VariableDeclaration) Pos() token.Pos { return s.Typ.Pos() }
+func (s *VariableDeclaration) End() token.Pos {
+	if s.Name != nil {
+		return s.Name.End()
+	}
+	if s.Location != nil {
+		return s.Location.End()
+	}
+	return s.Typ.End()
+}
+
+func (s *VariableDeclarationStmt) Pos() token.Pos {
+	if s.Lparen.IsValid() {
+		return s.Lparen
+	}
+	return s.Vars[0].Pos()
+}
+
+func (s *VariableDeclarationStmt) End() token.Pos { return s.Semicolon + 1 }
+
+func (s *BlockStmt) Pos() token.Pos { return s.Lbrace }
+func (s *BlockStmt) End() token.Pos { return s.Rbrace + 1 }
+
+func (s *UncheckedStmt) Pos() token.Pos { return s.Unchecked }
+func (s *UncheckedStmt) End() token.Pos { return s.Body.End() }
+
+func (s *IfStmt) Pos() token.Pos { return s.If }
+func (s *IfStmt) End() token.Pos {
+	if s.Else != nil {
+		return s.Else.End()
+	}
+	return s.Body.End()
+}
+
+func (s *ForStmt) Pos() token.Pos { return s.For }
+func (s *ForStmt) End() token.Pos { return s.Body.End() }
+
+func (s *WhileStmt) Pos() token.Pos { return s.While }
+func (s *WhileStmt) End() token.Pos { return s.Body.End() }
+
+func (s *DoWhileStmt) Pos() token.Pos { return s.Do }
+func (s *DoWhileStmt) End() token.Pos { return s.Semicolon + 1 }
+
+func (s *BranchStmt) Pos() token.Pos { return s.TokPos }
+func (s *BranchStmt) End() token.Pos { return s.Semicolon + 1 }
+
+func (s *ReturnStmt) Pos() token.Pos { return s.Return }
+func (s *ReturnStmt) End() token.Pos { return s.Semicolon + 1 }
+
+func (s *EmitStmt) Pos() token.Pos { return s.Emit }
+func (s *EmitStmt) End() token.Pos { return s.Semicolon + 1 }
+
+func (s *RevertStmt) Pos() token.Pos { return s.Revert }
+func (s *RevertStmt) End() token.Pos { return s.Semicolon + 1 }
+
+func (s *TryStmt) Pos() token.Pos { return s.Try }
+func (s *TryStmt) End() token.Pos {
+	if n := len(s.Catches); n > 0 {
+		return s.Catches[n-1].End()
+	}
+	return s.Body.End()
+}
+
+func (s *CatchClause) Pos() token.Pos { return s.Catch }
+func (s *CatchClause) End() token.Pos { return s.Body.End() }
+
+func (s *AssemblyStmt) Pos() token.Pos { return s.Assembly }
+func (s *AssemblyStmt) End() token.Pos { return s.Body.End() }
@@ -1,56 +1,259 @@
 package ast
 
 import (
+	"math/big"
+
 	"github.com/blockchain-labs-org/solzaemon/token"
-	protocol "github.com/sourcegraph/go-langserver/pkg/lsp"
+	"github.com/blockchain-labs-org/solzaemon/yul"
 )
 
-type Node interface{}
-type Stmt interface{}
-type Expr interface{}
+// Node is implemented by all nodes. A node spans the source from Pos up
+// to, but not including, End.
+type Node interface {
+	Pos() token.Pos // position of the first character of the node
+	End() token.Pos // position just after the last character of the node
+}
+
+// Stmt is implemented by all statement nodes.
+type Stmt interface {
+	Node
+}
+
+// Expr is implemented by all expression nodes.
+type Expr interface {
+	Node
+}
+
+// Decl is implemented by all directives and declarations at the file or
+// contract level.
+type Decl interface {
+	Node
+}
+
+// TypeName is implemented by all type name nodes. Type names and
+// expressions overlap: a conversion like `uint256(x)` calls a type name.
+type TypeName interface {
+	Node
+}
 
 type Program struct {
-	PragmaDirective    *PragmaDirective
+	PragmaDirectives   []*PragmaDirective
 	ImportDirectives   []*ImportDirective
 	ContractDefinition []*ContractPart
+	Units              []Decl // all directives and declarations in source order
 }
 
+// PragmaDirective is `pragma solidity ^0.8.0;`, `pragma abicoder v2;` or
+// `pragma experimental SMTChecker;`.
 type PragmaDirective struct {
-	Name  *Ident
-	Value string
+	Pragma    token.Pos
+	Name      *Ident
+	ValuePos  token.Pos
+	Value     string        // raw text between the name and the ';'
+	Version   *VersionRange // for `pragma solidity`, or nil
+	Semicolon token.Pos
 }
 
+// ImportDirective is `import "a.sol";`, `import "a.sol" as A;`,
+// `import * as A from "a.sol";` or `import {X as Y, Z} from "a.sol";`.
+// It spans from Import to just after Semicolon.
 type ImportDirective struct {
-	Path protocol.DocumentURI
+	Import    token.Pos
+	Star      token.Pos // position of `*`, or NoPos
+	Symbols   []*ImportSymbol
+	PathPos   token.Pos
+	Path      string // unquoted
+	Alias     *Ident // unit alias, or nil
+	Semicolon token.Pos
+}
+
+// ImportSymbol is `X` or `X as Y` in `import {X as Y} from "a.sol";`.
+type ImportSymbol struct {
+	Name  *Ident
+	Alias *Ident // or nil
 }
 
 type ContractPart struct {
+	Contract                  token.Pos   // position of abstract, or of the Kind keyword
+	Kind                      token.Token // CONTRACT, INTERFACE or LIBRARY
+	Abstract                  bool
 	Name                      *Ident
-	Inherits                  []*Ident
+	Inherits                  []*Ident    // names of Bases
+	Bases                     []*CallExpr // inheritance specifiers; Lparen and Rparen are NoPos without arguments
+	Lbrace                    token.Pos
 	StateVariableDeclarations []*StateVariableDeclaration
 	FunctionDefinitions       []*FunctionDefinition
+	Members                   []Decl // all members in source order
+	Rbrace                    token.Pos
 }
 
 type StateVariableDeclaration struct {
 	Name       *Ident
-	Typ        *Ident
+	Typ        TypeName
 	Rhs        Expr
 	IsConstant bool
+	Immutable  bool
 	Visibility string
+	Semicolon  token.Pos
 }
 
 type FunctionDefinition struct {
+	Function      token.Pos // position of function, constructor, fallback or receive
+	Name          *Ident    // or nil for an unnamed fallback function
+	Params        *ParameterList
+	Visibility    string    // public if not specified
+	VisibilityPos token.Pos // or NoPos if not specified
+	Mutability    string    // pure, view, payable, constant or ""
+	MutabilityPos token.Pos // or NoPos
+	Virtual       bool
+	Override      *OverrideSpecifier // or nil
+	Modifiers     []*CallExpr        // modifier invocations; Lparen and Rparen are NoPos without arguments
+	Returns       *ParameterList     // or nil
+	Body          *BlockStmt         // or nil
+	Semicolon     token.Pos          // or NoPos with a Body
+}
+
+// OverrideSpecifier is `override` or `override(A, B)`.
+type OverrideSpecifier struct {
+	Override  token.Pos
+	Lparen    token.Pos // or NoPos
+	Overrides []Expr    // *Ident or *SelectorExpr
+	Rparen    token.Pos // or NoPos
+}
+
+// UserDefinedValueTypeDefinition is `type Price is uint128;`.
+type UserDefinedValueTypeDefinition struct {
+	Type       token.Pos
 	Name       *Ident
-	Args       []Expr
-	Visibility string
-	Modifiers  []*Modifier
-	Returns    Returns
-	Block      []Stmt
+	Underlying TypeName
+	Semicolon  token.Pos
+}
+
+// ModifierDefinition is `modifier onlyOwner() { ...; _; }`.
+type ModifierDefinition struct {
+	Modifier  token.Pos
+	Name      *Ident
+	Params    *ParameterList // or nil without parentheses
+	Virtual   bool
+	Override  *OverrideSpecifier // or nil
+	Body      *BlockStmt         // or nil
+	Semicolon token.Pos          // or NoPos with a Body
+}
+
+// EventDefinition is `event Transfer(address indexed from, ...) anonymous;`.
+type EventDefinition struct {
+	Event     token.Pos
+	Name      *Ident
+	Params    *ParameterList
+	Anonymous bool
+	Semicolon token.Pos
+}
+
+// ErrorDefinition is `error InsufficientBalance(uint256 available);`.
+type ErrorDefinition struct {
+	Error     token.Pos // position of the error identifier
+	Name      *Ident
+	Params    *ParameterList
+	Semicolon token.Pos
+}
+
+// StructDefinition is `struct S { uint256 a; ... }`.
+type StructDefinition struct {
+	Struct token.Pos
+	Name   *Ident
+	Lbrace token.Pos
+	Fields []*Parameter
+	Rbrace token.Pos
+}
+
+// EnumDefinition is `enum E { A, B }`.
+type EnumDefinition struct {
+	Enum   token.Pos
+	Name   *Ident
+	Lbrace token.Pos
+	Values []*Ident
+	Rbrace token.Pos
 }
 
-type Modifier struct{}
-type Returns struct {
-	Typs []*Ident
+// UsingDirective is `using L for T;` or `using {f, g} for T global;`.
+type UsingDirective struct {
+	Using     token.Pos
+	Library   Expr     // *Ident or *SelectorExpr; or nil with Functions
+	Functions []Expr   // *Ident or *SelectorExpr
+	Typ       TypeName // or nil for `*`
+	Global    bool
+	Semicolon token.Pos
+}
+
+// ParameterList is a parenthesized list of parameters or return values.
+type ParameterList struct {
+	Lparen token.Pos
+	List   []*Parameter
+	Rparen token.Pos
+}
+
+// Parameter is a function, event or error parameter, a return value or a
+// struct field like `uint256[] memory amounts`.
+type Parameter struct {
+	Typ      TypeName
+	Indexed  token.Pos // position of indexed, or NoPos
+	Location *Ident    // memory, storage or calldata; or nil
+	Name     *Ident    // or nil
+}
+
+// ElementaryType is a built-in type name like uint256, bytes32 or
+// `address payable`.
+type ElementaryType struct {
+	NamePos token.Pos
+	Name    string
+	Payable token.Pos // position of payable in `address payable`, or NoPos
+}
+
+// UserDefinedType is the name of a contract, struct, enum or user-defined
+// value type, possibly qualified like `Lib.Point`.
+type UserDefinedType struct {
+	Path []*Ident
+}
+
+// Mapping is `mapping(Key KeyName => Value ValueName)`.
+type Mapping struct {
+	Mapping   token.Pos
+	Lparen    token.Pos
+	Key       TypeName // *ElementaryType, *UserDefinedType, or *BadExpr
+	KeyName   *Ident   // or nil
+	Value     TypeName
+	ValueName *Ident // or nil
+	Rparen    token.Pos
+}
+
+// ArrayType is `Elt[Len]`, or `Elt[]` for a dynamic array.
+type ArrayType struct {
+	Elt    TypeName
+	Lbrack token.Pos
+	Len    Expr // or nil
+	Rbrack token.Pos
+}
+
+// FunctionType is a function type like
+// `function (uint) external view returns (bool)`.
+type FunctionType struct {
+	Function      token.Pos
+	Params        *ParameterList
+	Visibility    string         // internal, external or ""
+	VisibilityPos token.Pos      // or NoPos
+	Mutability    string         // pure, view, payable or ""
+	MutabilityPos token.Pos      // or NoPos
+	Returns       *ParameterList // or nil
+}
+
+// BadExpr is a placeholder for an expression containing syntax errors.
+type BadExpr struct {
+	From, To token.Pos
+}
+
+// BadStmt is a placeholder for a statement containing syntax errors.
+type BadStmt struct {
+	From, To token.Pos
 }
 
 type Ident struct {
@@ -65,10 +268,36 @@ type BinaryExpr struct {
 	Y     Expr
 }
 
+// UnaryExpr is a prefix or postfix unary expression like `-x`, `!ok`,
+// `delete m[k]` or `i++`.
+type UnaryExpr struct {
+	OpPos   token.Pos
+	Op      token.Token
+	X       Expr
+	Postfix bool // for `x++` and `x--`
+}
+
+// ConditionalExpr is `Cond ? Then : Else`.
+type ConditionalExpr struct {
+	Cond     Expr
+	Question token.Pos
+	Then     Expr
+	Colon    token.Pos
+	Else     Expr
+}
+
+// TupleExpr is a parenthesized list like `(a, , c)`. Omitted components
+// are nil.
+type TupleExpr struct {
+	Lparen token.Pos
+	Elts   []Expr
+	Rparen token.Pos
+}
+
 type IndexExpr struct {
 	X      Expr
 	Lbrack token.Pos
-	Index  Expr
+	Index  Expr // or nil for an array type like `uint[]`
 	Rbrack token.Pos
 }
 
@@ -87,16 +316,194 @@ type BasicLit struct {
 	Kind     token.Token
 	Value    string
 	ValuePos token.Pos
+	Unit     *Ident   // denomination like ether or days, or nil
+	Number   *big.Rat // exact value of a number literal with Unit applied, or nil
+	Text     string   // decoded contents of a string literal
+}
+
+// EmptyStmt is a lone `;`.
+type EmptyStmt struct {
+	Semicolon token.Pos
+}
+
+// ExprStmt is an expression used as a statement.
+type ExprStmt struct {
+	X         Expr
+	Semicolon token.Pos
+}
+
+// VariableDeclaration declares a local variable like `uint[] memory xs`.
+type VariableDeclaration struct {
+	Typ      TypeName
+	Location *Ident // memory, storage, calldata, or nil
+	Name     *Ident
+}
+
+// VariableDeclarationStmt is `T x = Value;` or a tuple declaration like
+// `(T a, , T c) = Value;`.
+type VariableDeclarationStmt struct {
+	Lparen    token.Pos              // or NoPos without parentheses
+	Vars      []*VariableDeclaration // nil for omitted tuple components
+	Rparen    token.Pos              // or NoPos without parentheses
+	Value     Expr                   // or nil
+	Semicolon token.Pos
+}
+
+// BlockStmt is a braced statement list.
+type BlockStmt struct {
+	Lbrace token.Pos
+	List   []Stmt
+	Rbrace token.Pos
+}
+
+// UncheckedStmt is `unchecked { ... }`.
+type UncheckedStmt struct {
+	Unchecked token.Pos
+	Body      *BlockStmt
+}
+
+type IfStmt struct {
+	If      token.Pos
+	Cond    Expr
+	Body    Stmt
+	ElsePos token.Pos // or NoPos
+	Else    Stmt      // or nil
+}
+
+type ForStmt struct {
+	For  token.Pos
+	Init Stmt // or nil
+	Cond Expr // or nil
+	Post Expr // or nil
+	Body Stmt
+}
+
+type WhileStmt struct {
+	While token.Pos
+	Cond  Expr
+	Body  Stmt
+}
+
+// DoWhileStmt is `do Body while (Cond);`.
+type DoWhileStmt struct {
+	Do        token.Pos
+	Body      Stmt
+	While     token.Pos
+	Cond      Expr
+	Semicolon token.Pos
+}
+
+// BranchStmt is `break;` or `continue;`.
+type BranchStmt struct {
+	TokPos    token.Pos
+	Tok       token.Token // BREAK or CONTINUE
+	Semicolon token.Pos
+}
+
+type ReturnStmt struct {
+	Return    token.Pos
+	Result    Expr // or nil
+	Semicolon token.Pos
 }
 
-type AssignStmt struct {
-	Lhs []Expr
-	Rhs []Expr
+// EmitStmt is `emit Event(args);`.
+type EmitStmt struct {
+	Emit      token.Pos
+	Call      Expr // *CallExpr, or *BadExpr
+	Semicolon token.Pos
 }
 
+// RevertStmt is `revert CustomError(args);`. `revert("reason")` is an
+// ordinary call.
+type RevertStmt struct {
+	Revert    token.Pos
+	Call      Expr // *CallExpr, or *BadExpr
+	Semicolon token.Pos
+}
+
+// TryStmt is `try Call returns (...) { ... } catch ... { ... }`.
+type TryStmt struct {
+	Try     token.Pos
+	Call    Expr
+	Returns *ParameterList // or nil
+	Body    *BlockStmt
+	Catches []*CatchClause
+}
+
+// CatchClause is `catch Error(string memory reason) { ... }`.
+type CatchClause struct {
+	Catch  token.Pos
+	Name   *Ident         // Error, Panic or nil
+	Params *ParameterList // or nil
+	Body   *BlockStmt
+}
+
+// AssemblyStmt is an inline assembly block.
+type AssemblyStmt struct {
+	Assembly token.Pos
+	Dialect  *BasicLit   // like "evmasm", or nil
+	Flags    []*BasicLit // like "memory-safe"
+	Body     *yul.Block
+}
+
+// CallExpr is a function call or a type conversion. The arguments are
+// either positional, like `f(1, 2)`, or named, like `f({a: 1, b: 2})`.
 type CallExpr struct {
-	Fun    Expr
+	Fun       Expr
+	Lparen    token.Pos
+	Args      []Expr        // positional arguments
+	NamedArgs *NamedArgList // or nil
+	Rparen    token.Pos
+}
+
+// NamedArgList is a braced list of named arguments or call options.
+type NamedArgList struct {
+	Lbrace token.Pos
+	List   []*NamedArg
+	Rbrace token.Pos
+}
+
+// NamedArg is `Name: Value`.
+type NamedArg struct {
+	Name  *Ident
+	Colon token.Pos
+	Value Expr
+}
+
+// CallOptions is `X{value: v, gas: g}`, as in
+// `addr.call{value: v}("")`.
+type CallOptions struct {
+	X       Expr
+	Options *NamedArgList
+}
+
+// IndexRangeExpr is a slice of a calldata array like `x[Low:High]`.
+type IndexRangeExpr struct {
+	X      Expr
+	Lbrack token.Pos
+	Low    Expr // or nil
+	Colon  token.Pos
+	High   Expr // or nil
+	Rbrack token.Pos
+}
+
+// NewExpr is `new Typ`, called like `new C(x)` or `new uint[](n)`.
+type NewExpr struct {
+	New token.Pos
+	Typ TypeName
+}
+
+// MetaTypeExpr is `type(Typ)`, as in `type(uint8).max`.
+type MetaTypeExpr struct {
+	Type   token.Pos
 	Lparen token.Pos
-	Args   []Expr
+	Typ    TypeName
 	Rparen token.Pos
 }
+
+// ArrayLit is an inline array like `[1, 2, 3]`.
+type ArrayLit struct {
+	Lbrack token.Pos
+	Elts   []Expr
+	Rbrack token.Pos
+}
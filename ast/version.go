@@ -0,0 +1,154 @@
+package ast
+
+import "github.com/blockchain-labs-org/solzaemon/token"
+
+// VersionRange is the version constraint of `pragma solidity`, like
+// `>=0.6.0 <0.9.0 || ^0.8.0`. A version matches if it matches any of the
+// sets separated by `||`.
+type VersionRange struct {
+	Sets []*VersionSet
+}
+
+// VersionSet is a list of constraints that a version must all match, like
+// `>=0.6.0 <0.9.0`.
+type VersionSet struct {
+	Constraints []*VersionConstraint
+}
+
+// VersionConstraint is a version with an optional operator like `^0.8.0`,
+// or a hyphen range like `0.6.0 - 0.8.0`.
+type VersionConstraint struct {
+	OpPos   token.Pos      // or NoPos without an operator
+	Op      token.Token    // ILLEGAL, ASSIGN, XOR, TILDE, LSS, LEQ, GTR, GEQ, or SUB for a hyphen range
+	Version *VersionNumber // the lower bound of a hyphen range
+	Upper   *VersionNumber // the upper bound of a hyphen range, or nil
+}
+
+// VersionNumber is a possibly partial version like `0.8.19`, `0.8` or
+// `0.8.x`.
+type VersionNumber struct {
+	ValuePos token.Pos
+	Value    string
+	Numbers  []int // the leading components up to the first wildcard
+}
+
+func (r *VersionRange) Pos() token.Pos {
+	if len(r.Sets) > 0 {
+		return r.Sets[0].Pos()
+	}
+	return token.NoPos
+}
+
+func (r *VersionRange) End() token.Pos {
+	if n := len(r.Sets); n > 0 {
+		return r.Sets[n-1].End()
+	}
+	return token.NoPos
+}
+
+func (s *VersionSet) Pos() token.Pos {
+	if len(s.Constraints) > 0 {
+		return s.Constraints[0].Pos()
+	}
+	return token.NoPos
+}
+
+func (s *VersionSet) End() token.Pos {
+	if n := len(s.Constraints); n > 0 {
+		return s.Constraints[n-1].End()
+	}
+	return token.NoPos
+}
+
+// Pos returns the position of the operator, or of the version without an
+// operator. The operator of a hyphen range is between the versions.
+func (c *VersionConstraint) Pos() token.Pos {
+	if c.OpPos.IsValid() && c.Op != token.SUB {
+		return c.OpPos
+	}
+	return c.Version.Pos()
+}
+
+func (c *VersionConstraint) End() token.Pos {
+	if c.Upper != nil {
+		return c.Upper.End()
+	}
+	return c.Version.End()
+}
+
+func (n *VersionNumber) Pos() token.Pos { return n.ValuePos }
+func (n *VersionNumber) End() token.Pos { return textEnd(n.ValuePos, n.Value) }
+
+// Match reports whether the version major.minor.patch satisfies r.
+func (r *VersionRange) Match(major, minor, patch int) bool {
+	for _, set := range r.Sets {
+		if set.Match(major, minor, patch) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether the version major.minor.patch satisfies all the
+// constraints of s.
+func (s *VersionSet) Match(major, minor, patch int) bool {
+	for _, c := range s.Constraints {
+		if !c.Match(major, minor, patch) {
+			return false
+		}
+	}
+	return true
+}
+
+// Match reports whether the version major.minor.patch satisfies c. Partial
+// versions compare only their components, so `0.8` equals 0.8.19 and `<=0.8`
+// includes it.
+func (c *VersionConstraint) Match(major, minor, patch int) bool {
+	v := [3]int{major, minor, patch}
+	n := c.Version.Numbers
+	cmp := compareVersion(v, n)
+	switch c.Op {
+	case token.LSS:
+		return cmp < 0
+	case token.LEQ:
+		return cmp <= 0
+	case token.GTR:
+		return cmp > 0
+	case token.GEQ:
+		return cmp >= 0
+	case token.SUB:
+		return cmp >= 0 && compareVersion(v, c.Upper.Numbers) <= 0
+	case token.TILDE:
+		// changes of the patch version, or of the minor version if
+		// it is not given
+		k := len(n)
+		if k > 2 {
+			k = 2
+		}
+		return cmp >= 0 && compareVersion(v, n[:k]) == 0
+	case token.XOR:
+		// changes not modifying the left-most non-zero component
+		k := 0
+		for k < len(n) && n[k] == 0 {
+			k++
+		}
+		if k < len(n) {
+			k++
+		}
+		return cmp >= 0 && compareVersion(v, n[:k]) == 0
+	}
+	return cmp == 0
+}
+
+// compareVersion compares v with the leading components n of a version.
+func compareVersion(v [3]int, n []int) int {
+	for i, x := range n {
+		switch {
+		case v[i] < x:
+			return -1
+		case v[i] > x:
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,501 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/blockchain-labs-org/solzaemon/yul"
+)
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of node with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+func walkIdentList(v Visitor, list []*Ident) {
+	for _, x := range list {
+		Walk(v, x)
+	}
+}
+
+// walkExprList walks the expressions of list, skipping omitted ones.
+func walkExprList(v Visitor, list []Expr) {
+	for _, x := range list {
+		if x != nil {
+			Walk(v, x)
+		}
+	}
+}
+
+func walkStmtList(v Visitor, list []Stmt) {
+	for _, x := range list {
+		Walk(v, x)
+	}
+}
+
+func walkDeclList(v Visitor, list []Decl) {
+	for _, x := range list {
+		Walk(v, x)
+	}
+}
+
+func walkYulIdentList(v Visitor, list []*yul.Ident) {
+	for _, x := range list {
+		Walk(v, x)
+	}
+}
+
+func walkYulExprList(v Visitor, list []yul.Expr) {
+	for _, x := range list {
+		Walk(v, x)
+	}
+}
+
+func walkYulStmtList(v Visitor, list []yul.Stmt) {
+	for _, x := range list {
+		Walk(v, x)
+	}
+}
+
+// Walk traverses an AST in depth-first order: It starts by calling
+// v.Visit(node); node must not be nil. If the visitor w returned by
+// v.Visit(node) is not nil, Walk is invoked recursively with visitor
+// w for each of the non-nil children of node, in source order, followed
+// by a call of w.Visit(nil).
+//
+// The Yul nodes of inline assembly are visited as well. The lists of
+// Program and ContractPart by kind repeat Units and Members, and are not
+// visited; neither are the Inherits of a ContractPart, which repeat the
+// names of its Bases.
+//
+// Only children that the grammar makes optional may be nil; the parser
+// fills the others with Bad nodes when it recovers from an error.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	// walk children
+	switch n := node.(type) {
+	// Directives and declarations
+	case *Program:
+		walkDeclList(v, n.Units)
+
+	case *PragmaDirective:
+		Walk(v, n.Name)
+		if n.Version != nil {
+			Walk(v, n.Version)
+		}
+
+	case *ImportDirective:
+		for _, sym := range n.Symbols {
+			Walk(v, sym)
+		}
+		if n.Alias != nil {
+			Walk(v, n.Alias)
+		}
+
+	case *ImportSymbol:
+		Walk(v, n.Name)
+		if n.Alias != nil {
+			Walk(v, n.Alias)
+		}
+
+	case *ContractPart:
+		Walk(v, n.Name)
+		for _, base := range n.Bases {
+			Walk(v, base)
+		}
+		walkDeclList(v, n.Members)
+
+	case *StateVariableDeclaration:
+		Walk(v, n.Typ)
+		Walk(v, n.Name)
+		if n.Rhs != nil {
+			Walk(v, n.Rhs)
+		}
+
+	case *FunctionDefinition:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		Walk(v, n.Params)
+		// the override specifier may be anywhere among the modifiers
+		override := n.Override
+		for _, m := range n.Modifiers {
+			if override != nil && override.Pos() < m.Pos() {
+				Walk(v, override)
+				override = nil
+			}
+			Walk(v, m)
+		}
+		if override != nil {
+			Walk(v, override)
+		}
+		if n.Returns != nil {
+			Walk(v, n.Returns)
+		}
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+
+	case *OverrideSpecifier:
+		walkExprList(v, n.Overrides)
+
+	case *UserDefinedValueTypeDefinition:
+		Walk(v, n.Name)
+		Walk(v, n.Underlying)
+
+	case *ModifierDefinition:
+		Walk(v, n.Name)
+		if n.Params != nil {
+			Walk(v, n.Params)
+		}
+		if n.Override != nil {
+			Walk(v, n.Override)
+		}
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+
+	case *EventDefinition:
+		Walk(v, n.Name)
+		Walk(v, n.Params)
+
+	case *ErrorDefinition:
+		Walk(v, n.Name)
+		Walk(v, n.Params)
+
+	case *StructDefinition:
+		Walk(v, n.Name)
+		for _, f := range n.Fields {
+			Walk(v, f)
+		}
+
+	case *EnumDefinition:
+		Walk(v, n.Name)
+		walkIdentList(v, n.Values)
+
+	case *UsingDirective:
+		if n.Library != nil {
+			Walk(v, n.Library)
+		}
+		walkExprList(v, n.Functions)
+		if n.Typ != nil {
+			Walk(v, n.Typ)
+		}
+
+	case *ParameterList:
+		for _, p := range n.List {
+			Walk(v, p)
+		}
+
+	case *Parameter:
+		Walk(v, n.Typ)
+		if n.Location != nil {
+			Walk(v, n.Location)
+		}
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+
+	// Type names
+	case *ElementaryType:
+		// nothing to do
+
+	case *UserDefinedType:
+		walkIdentList(v, n.Path)
+
+	case *Mapping:
+		Walk(v, n.Key)
+		if n.KeyName != nil {
+			Walk(v, n.KeyName)
+		}
+		Walk(v, n.Value)
+		if n.ValueName != nil {
+			Walk(v, n.ValueName)
+		}
+
+	case *ArrayType:
+		Walk(v, n.Elt)
+		if n.Len != nil {
+			Walk(v, n.Len)
+		}
+
+	case *FunctionType:
+		Walk(v, n.Params)
+		if n.Returns != nil {
+			Walk(v, n.Returns)
+		}
+
+	// Expressions
+	case *BadExpr, *Ident:
+		// nothing to do
+
+	case *BinaryExpr:
+		Walk(v, n.X)
+		Walk(v, n.Y)
+
+	case *UnaryExpr:
+		Walk(v, n.X)
+
+	case *ConditionalExpr:
+		Walk(v, n.Cond)
+		Walk(v, n.Then)
+		Walk(v, n.Else)
+
+	case *TupleExpr:
+		walkExprList(v, n.Elts)
+
+	case *IndexExpr:
+		Walk(v, n.X)
+		if n.Index != nil {
+			Walk(v, n.Index)
+		}
+
+	case *SelectorExpr:
+		Walk(v, n.X)
+		Walk(v, n.Sel)
+
+	case *ParenExpr:
+		Walk(v, n.X)
+
+	case *BasicLit:
+		if n.Unit != nil {
+			Walk(v, n.Unit)
+		}
+
+	case *CallExpr:
+		Walk(v, n.Fun)
+		walkExprList(v, n.Args)
+		if n.NamedArgs != nil {
+			Walk(v, n.NamedArgs)
+		}
+
+	case *NamedArgList:
+		for _, arg := range n.List {
+			Walk(v, arg)
+		}
+
+	case *NamedArg:
+		Walk(v, n.Name)
+		Walk(v, n.Value)
+
+	case *CallOptions:
+		Walk(v, n.X)
+		Walk(v, n.Options)
+
+	case *IndexRangeExpr:
+		Walk(v, n.X)
+		if n.Low != nil {
+			Walk(v, n.Low)
+		}
+		if n.High != nil {
+			Walk(v, n.High)
+		}
+
+	case *NewExpr:
+		Walk(v, n.Typ)
+
+	case *MetaTypeExpr:
+		Walk(v, n.Typ)
+
+	case *ArrayLit:
+		walkExprList(v, n.Elts)
+
+	// Statements
+	case *BadStmt, *EmptyStmt, *BranchStmt:
+		// nothing to do
+
+	case *ExprStmt:
+		Walk(v, n.X)
+
+	case *VariableDeclaration:
+		Walk(v, n.Typ)
+		if n.Location != nil {
+			Walk(v, n.Location)
+		}
+		Walk(v, n.Name)
+
+	case *VariableDeclarationStmt:
+		for _, x := range n.Vars {
+			if x != nil {
+				Walk(v, x)
+			}
+		}
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+
+	case *BlockStmt:
+		walkStmtList(v, n.List)
+
+	case *UncheckedStmt:
+		Walk(v, n.Body)
+
+	case *IfStmt:
+		Walk(v, n.Cond)
+		Walk(v, n.Body)
+		if n.Else != nil {
+			Walk(v, n.Else)
+		}
+
+	case *ForStmt:
+		if n.Init != nil {
+			Walk(v, n.Init)
+		}
+		if n.Cond != nil {
+			Walk(v, n.Cond)
+		}
+		if n.Post != nil {
+			Walk(v, n.Post)
+		}
+		Walk(v, n.Body)
+
+	case *WhileStmt:
+		Walk(v, n.Cond)
+		Walk(v, n.Body)
+
+	case *DoWhileStmt:
+		Walk(v, n.Body)
+		Walk(v, n.Cond)
+
+	case *ReturnStmt:
+		if n.Result != nil {
+			Walk(v, n.Result)
+		}
+
+	case *EmitStmt:
+		Walk(v, n.Call)
+
+	case *RevertStmt:
+		Walk(v, n.Call)
+
+	case *TryStmt:
+		Walk(v, n.Call)
+		if n.Returns != nil {
+			Walk(v, n.Returns)
+		}
+		Walk(v, n.Body)
+		for _, c := range n.Catches {
+			Walk(v, c)
+		}
+
+	case *CatchClause:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		if n.Params != nil {
+			Walk(v, n.Params)
+		}
+		Walk(v, n.Body)
+
+	case *AssemblyStmt:
+		if n.Dialect != nil {
+			Walk(v, n.Dialect)
+		}
+		for _, flag := range n.Flags {
+			Walk(v, flag)
+		}
+		Walk(v, n.Body)
+
+	// Pragma versions
+	case *VersionRange:
+		for _, set := range n.Sets {
+			Walk(v, set)
+		}
+
+	case *VersionSet:
+		for _, c := range n.Constraints {
+			Walk(v, c)
+		}
+
+	case *VersionConstraint:
+		Walk(v, n.Version)
+		if n.Upper != nil {
+			Walk(v, n.Upper)
+		}
+
+	case *VersionNumber:
+		// nothing to do
+
+	// Yul
+	case *yul.Block:
+		walkYulStmtList(v, n.List)
+
+	case *yul.VariableDeclaration:
+		walkYulIdentList(v, n.Names)
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+
+	case *yul.Assignment:
+		walkYulExprList(v, n.Lhs)
+		Walk(v, n.Value)
+
+	case *yul.ExprStmt:
+		Walk(v, n.X)
+
+	case *yul.If:
+		Walk(v, n.Cond)
+		Walk(v, n.Body)
+
+	case *yul.Switch:
+		Walk(v, n.X)
+		for _, c := range n.Cases {
+			Walk(v, c)
+		}
+
+	case *yul.Case:
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+		Walk(v, n.Body)
+
+	case *yul.For:
+		Walk(v, n.Init)
+		Walk(v, n.Cond)
+		Walk(v, n.Post)
+		Walk(v, n.Body)
+
+	case *yul.FunctionDefinition:
+		Walk(v, n.Name)
+		walkYulIdentList(v, n.Params)
+		walkYulIdentList(v, n.Returns)
+		Walk(v, n.Body)
+
+	case *yul.MemberAccess:
+		Walk(v, n.X)
+		Walk(v, n.Member)
+
+	case *yul.FunctionCall:
+		Walk(v, n.Fun)
+		walkYulExprList(v, n.Args)
+
+	case *yul.BranchStmt, *yul.BadStmt, *yul.BadExpr, *yul.Ident, *yul.Literal:
+		// nothing to do
+
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order: It starts by calling
+// f(node); node must not be nil. If f returns true, Inspect invokes f
+// recursively for each of the non-nil children of node, followed by a
+// call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
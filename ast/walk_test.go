@@ -0,0 +1,142 @@
+package ast_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ToQoz/gopwt/assert"
+	"github.com/blockchain-labs-org/solzaemon/ast"
+	"github.com/blockchain-labs-org/solzaemon/parser"
+	"github.com/blockchain-labs-org/solzaemon/token"
+)
+
+const src = `pragma solidity >=0.6.0 <0.9.0;
+import {A as B} from "./a.sol";
+
+contract C is B {
+	using L for uint256;
+	type Price is uint128;
+	uint256 constant X = 1 ether;
+	mapping(address owner => uint256[]) balances;
+	event E(address indexed from);
+	error Err(uint256 code);
+	struct S { uint256 a; }
+	enum K { Y, Z }
+	modifier m(uint256 n) virtual { _; }
+	S[] items;
+
+	function f(function (uint256) external g) public onlyOwner override(B) returns (uint256 r) {
+		(uint256 x, ) = (1, 2);
+		for (uint256 i; i < x; i++) { if (i == 0) continue; else break; }
+		while (x > 0) x--;
+		do { x = x ? 1 : 2; } while (false);
+		emit E(msg.sender);
+		addr.call{value: 1}(msg.data[4:]);
+		new uint256[](type(uint8).max);
+		try this.h({a: [1, 2]}) returns (uint256 y) {} catch Error(string memory s) { revert Err(0); }
+		unchecked { return -x; }
+		assembly {
+			let v, w := f(x.slot)
+			switch v case 0 { leave } default {}
+			for { } lt(v, 1) { } { break }
+			if w { function h() -> q { q := "a" } }
+		}
+		;
+	}
+}`
+
+func parse(t *testing.T) (*token.File, *ast.Program) {
+	runes := []rune(src)
+	f := token.NewFileSet().AddFile("", -1, len(runes))
+	p, err := parser.Parse(f, runes)
+	assert.Require(t, err == nil)
+	return f, p
+}
+
+func TestInspect(t *testing.T) {
+	_, p := parse(t)
+
+	// nodes are visited in source order, and within their parents
+	var stack []ast.Node
+	var last token.Pos
+	ordered, nested := true, true
+	seen := map[string]bool{}
+	ast.Inspect(p, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return false
+		}
+		seen[fmt.Sprintf("%T", n)] = true
+		if n.Pos() < last {
+			ordered = false
+		}
+		last = n.Pos()
+		if len(stack) > 0 {
+			parent := stack[len(stack)-1]
+			if n.Pos() < parent.Pos() || n.End() > parent.End() {
+				nested = false
+			}
+		}
+		stack = append(stack, n)
+		return true
+	})
+	assert.OK(t, ordered)
+	assert.OK(t, nested)
+	assert.OK(t, len(stack) == 0)
+
+	for _, typ := range []string{
+		"*ast.Program", "*ast.PragmaDirective", "*ast.VersionRange", "*ast.VersionSet",
+		"*ast.VersionConstraint", "*ast.VersionNumber", "*ast.ImportDirective", "*ast.ImportSymbol",
+		"*ast.ContractPart", "*ast.UsingDirective", "*ast.UserDefinedValueTypeDefinition",
+		"*ast.StateVariableDeclaration", "*ast.Mapping", "*ast.ArrayType", "*ast.EventDefinition",
+		"*ast.ErrorDefinition", "*ast.StructDefinition", "*ast.EnumDefinition", "*ast.ModifierDefinition",
+		"*ast.FunctionDefinition", "*ast.FunctionType", "*ast.OverrideSpecifier", "*ast.ParameterList",
+		"*ast.Parameter", "*ast.ElementaryType", "*ast.UserDefinedType", "*ast.Ident", "*ast.BasicLit",
+		"*ast.VariableDeclarationStmt", "*ast.VariableDeclaration", "*ast.TupleExpr", "*ast.ForStmt",
+		"*ast.IfStmt", "*ast.BranchStmt", "*ast.WhileStmt", "*ast.DoWhileStmt", "*ast.ConditionalExpr",
+		"*ast.EmitStmt", "*ast.RevertStmt", "*ast.ReturnStmt", "*ast.UncheckedStmt", "*ast.TryStmt",
+		"*ast.CatchClause", "*ast.BlockStmt", "*ast.ExprStmt", "*ast.EmptyStmt", "*ast.BinaryExpr",
+		"*ast.UnaryExpr", "*ast.CallExpr", "*ast.CallOptions", "*ast.NamedArgList", "*ast.NamedArg",
+		"*ast.SelectorExpr", "*ast.IndexRangeExpr", "*ast.NewExpr", "*ast.MetaTypeExpr", "*ast.ArrayLit",
+		"*ast.AssemblyStmt", "*yul.Block", "*yul.VariableDeclaration", "*yul.FunctionCall",
+		"*yul.MemberAccess", "*yul.Ident", "*yul.Switch", "*yul.Case", "*yul.Literal", "*yul.BranchStmt",
+		"*yul.For", "*yul.If", "*yul.FunctionDefinition", "*yul.Assignment",
+	} {
+		assert.OK(t, seen[typ], typ)
+	}
+}
+
+// identCounter counts identifiers outside of function bodies.
+type identCounter struct {
+	n int
+}
+
+func (v *identCounter) Visit(node ast.Node) ast.Visitor {
+	switch node.(type) {
+	case *ast.BlockStmt:
+		return nil
+	case *ast.Ident:
+		v.n++
+	}
+	return v
+}
+
+func TestWalk(t *testing.T) {
+	_, p := parse(t)
+	contract := p.ContractDefinition[0]
+	f := contract.FunctionDefinitions[0]
+
+	v := &identCounter{}
+	ast.Walk(v, f)
+	// f, g, onlyOwner, B, r
+	assert.OK(t, v.n == 5)
+
+	var names []string
+	ast.Inspect(contract.Members[8], func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			names = append(names, id.Name)
+		}
+		return true
+	})
+	assert.OK(t, fmt.Sprint(names) == "[m n _]")
+}
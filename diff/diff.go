@@ -0,0 +1,220 @@
+// Package diff computes the differences between the lines of two texts.
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// maxCost bounds the number of inserted and deleted lines a minimal diff is
+// searched for. Texts differing more are replaced as a whole, which is
+// cheap and still correct.
+const maxCost = 1000
+
+// context is the number of unchanged lines around the changes of a hunk in
+// a unified diff.
+const context = 3
+
+// An Edit replaces the lines a[Start:End] of an old text with the lines
+// New. An insertion has Start == End.
+type Edit struct {
+	Start, End int
+	New        []string
+}
+
+// Lines splits s into lines, each keeping its terminating newline. The
+// last line has no newline if s does not end with one.
+func Lines(s string) []string {
+	var lines []string
+	for s != "" {
+		i := strings.IndexByte(s, '\n') + 1
+		if i == 0 {
+			i = len(s)
+		}
+		lines = append(lines, s[:i])
+		s = s[i:]
+	}
+	return lines
+}
+
+// Edits returns the edits turning the lines a into the lines b, ordered by
+// position and not overlapping. The number of changed lines is minimal
+// unless the texts differ in a lot of lines.
+func Edits(a, b []string) []Edit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	edits := myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	for i := range edits {
+		edits[i].Start += prefix
+		edits[i].End += prefix
+	}
+	return edits
+}
+
+// myers finds the edits turning a into b with the algorithm of E. Myers,
+// "An O(ND) Difference Algorithm and Its Variations".
+func myers(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+	max := n + m
+	// v[max+k] is the furthest x reached on diagonal k = x - y
+	v := make([]int, 2*max+2)
+	// trace[d] holds v[max-d:max+d+1] before step d
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		if d > maxCost {
+			return []Edit{{Start: 0, End: n, New: b}}
+		}
+		trace = append(trace, append([]int(nil), v[max-d:max+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[max+k-1] < v[max+k+1] {
+				x = v[max+k+1] // down: insert b[y-1]
+			} else {
+				x = v[max+k-1] + 1 // right: delete a[x-1]
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[max+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b)
+			}
+		}
+	}
+	panic("unreachable")
+}
+
+// backtrack follows the path found by myers and returns the gaps between
+// its matching lines.
+func backtrack(trace [][]int, a, b []string) []Edit {
+	// matching lines (x, y) from the end
+	var xs, ys []int
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := func(k int) int { return trace[d][k+d] }
+		k := x - y
+		var pk int
+		if k == -d || k != d && prev(k-1) < prev(k+1) {
+			pk = k + 1
+		} else {
+			pk = k - 1
+		}
+		px := prev(pk)
+		py := px - pk
+		// the snake following the insertion or deletion
+		for x > px && y > py {
+			x--
+			y--
+			xs = append(xs, x)
+			ys = append(ys, y)
+		}
+		x, y = px, py
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		xs = append(xs, x)
+		ys = append(ys, y)
+	}
+
+	var edits []Edit
+	i, j := 0, 0
+	for n := len(xs) - 1; n >= -1; n-- {
+		mx, my := len(a), len(b)
+		if n >= 0 {
+			mx, my = xs[n], ys[n]
+		}
+		if i < mx || j < my {
+			edits = append(edits, Edit{Start: i, End: mx, New: b[j:my]})
+		}
+		i, j = mx+1, my+1
+	}
+	return edits
+}
+
+// Unified returns the differences between the texts a and b in the unified
+// format, with oldName and newName in its header. It returns "" if the
+// texts are equal.
+func Unified(oldName, newName, a, b string) string {
+	lines := Lines(a)
+	edits := Edits(lines, Lines(b))
+	if len(edits) == 0 {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", oldName, newName)
+	delta := 0 // the line number in b minus the one in a
+	for i := 0; i < len(edits); {
+		// a hunk covers the edits whose context overlaps
+		j := i + 1
+		for j < len(edits) && edits[j].Start-edits[j-1].End <= 2*context {
+			j++
+		}
+		start := edits[i].Start - context
+		if start < 0 {
+			start = 0
+		}
+		end := edits[j-1].End + context
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		var hunk bytes.Buffer
+		newStart, newCount := start+delta, end-start
+		line := start
+		for _, e := range edits[i:j] {
+			for ; line < e.Start; line++ {
+				writeLine(&hunk, ' ', lines[line])
+			}
+			for ; line < e.End; line++ {
+				writeLine(&hunk, '-', lines[line])
+			}
+			for _, l := range e.New {
+				writeLine(&hunk, '+', l)
+			}
+			newCount += len(e.New) - (e.End - e.Start)
+		}
+		delta += newCount - (end - start)
+		for ; line < end; line++ {
+			writeLine(&hunk, ' ', lines[line])
+		}
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n", hunkRange(start, end-start), hunkRange(newStart, newCount))
+		buf.Write(hunk.Bytes())
+		i = j
+	}
+	return buf.String()
+}
+
+// hunkRange formats the range of count lines from the 0-based line start.
+// An empty range is given by the line before it.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func writeLine(buf *bytes.Buffer, prefix byte, line string) {
+	buf.WriteByte(prefix)
+	buf.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		buf.WriteString("\n\\ No newline at end of file\n")
+	}
+}
@@ -0,0 +1,105 @@
+package diff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ToQoz/gopwt/assert"
+)
+
+// apply applies edits to the lines a.
+func apply(a []string, edits []Edit) []string {
+	var b []string
+	i := 0
+	for _, e := range edits {
+		b = append(b, a[i:e.Start]...)
+		b = append(b, e.New...)
+		i = e.End
+	}
+	return append(b, a[i:]...)
+}
+
+func TestLines(t *testing.T) {
+	assert.OK(t, Lines("") == nil)
+	assert.OK(t, reflect.DeepEqual(Lines("a\n\nb"), []string{"a\n", "\n", "b"}))
+	assert.OK(t, reflect.DeepEqual(Lines("a\nb\n"), []string{"a\n", "b\n"}))
+}
+
+func TestEdits(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		changed int // inserted and deleted lines
+	}{
+		{"", "", 0},
+		{"a\nb\n", "a\nb\n", 0},
+		{"", "a\nb\n", 2},
+		{"a\nb\n", "", 2},
+		{"a\nb\nc\n", "a\nc\n", 1},
+		{"a\nc\n", "a\nb\nc\n", 1},
+		{"a\nb\nc\nd\n", "a\nx\nc\ny\n", 4},
+		{"a\nb\nc\na\nb\nb\na\n", "c\nb\na\nb\na\nc\n", 5},
+		{"x\na\nb\n", "a\nb\ny\n", 2},
+		{"a\nb", "a\nb\n", 2},
+	}
+	for _, tt := range tests {
+		a, b := Lines(tt.a), Lines(tt.b)
+		edits := Edits(a, b)
+		assert.OK(t, strings.Join(apply(a, edits), "") == tt.b, tt.a)
+
+		changed := 0
+		for i, e := range edits {
+			assert.OK(t, e.Start < e.End || len(e.New) > 0, tt.a)
+			if i > 0 {
+				assert.OK(t, edits[i-1].End < e.Start, tt.a)
+			}
+			changed += e.End - e.Start + len(e.New)
+		}
+		assert.OK(t, changed == tt.changed, tt.a)
+	}
+
+	// texts differing in too many lines are replaced as a whole
+	var a, b []string
+	for i := 0; i <= maxCost; i++ {
+		a = append(a, "a\n")
+		b = append(b, "b\n")
+	}
+	edits := Edits(a, b)
+	assert.OK(t, len(edits) == 1)
+	assert.OK(t, reflect.DeepEqual(apply(a, edits), b))
+}
+
+func TestUnified(t *testing.T) {
+	assert.OK(t, Unified("a", "b", "x\n", "x\n") == "")
+
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n"
+	b := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n15\nsixteen"
+	expected := `--- a.sol.orig
++++ a.sol
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -11,5 +11,5 @@
+ 11
+ 12
+ 13
+-14
+ 15
++sixteen
+\ No newline at end of file
+`
+	assert.OK(t, Unified("a.sol.orig", "a.sol", a, b) == expected)
+
+	// insertions into an empty text
+	expected = `--- a
++++ b
+@@ -0,0 +1 @@
++x
+`
+	assert.OK(t, Unified("a", "b", "", "x\n") == expected)
+}
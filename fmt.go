@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blockchain-labs-org/solzaemon/diff"
+	"github.com/blockchain-labs-org/solzaemon/printer"
+)
+
+// runFmt implements "solzaemon fmt [-w] [-d] [path ...]", which formats
+// the given Solidity files, or the .sol files in the given directories,
+// and prints the results. Without paths, it formats stdin. It returns the
+// exit code.
+func runFmt(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	write := flags.Bool("w", false, "write result to (source) file instead of stdout")
+	list := flags.Bool("d", false, "display diffs instead of rewriting files")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: solzaemon fmt [flags] [path ...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(stderr, "solzaemon fmt: cannot use -w with standard input")
+			return 2
+		}
+		src, err := ioutil.ReadAll(stdin)
+		if err == nil {
+			err = formatFile("<standard input>", src, 0, false, *list, stdout)
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		return 0
+	}
+
+	code := 0
+	for _, arg := range flags.Args() {
+		err := filepath.Walk(arg, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			// format the files given, and the .sol files in the directories given
+			if !info.Mode().IsRegular() || path != arg && !isSolFile(info) {
+				return nil
+			}
+			src, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			// report the file and go on with the others
+			if err := formatFile(path, src, info.Mode().Perm(), *write, *list, stdout); err != nil {
+				fmt.Fprintln(stderr, err)
+				code = 2
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			code = 2
+		}
+	}
+	return code
+}
+
+func isSolFile(info os.FileInfo) bool {
+	return !strings.HasPrefix(info.Name(), ".") && strings.HasSuffix(info.Name(), ".sol")
+}
+
+// formatFile formats src, the contents of the file at path. It writes the
+// result back with permissions perm if write is set, prints the
+// differences if list is set, and prints the result otherwise.
+func formatFile(path string, src []byte, perm os.FileMode, write, list bool, stdout io.Writer) error {
+	res, err := printer.Source(src)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	if list {
+		io.WriteString(stdout, diff.Unified(path+".orig", path, string(src), string(res)))
+	}
+	if write {
+		if string(res) == string(src) {
+			return nil
+		}
+		return ioutil.WriteFile(path, res, perm)
+	}
+	if !list {
+		_, err = stdout.Write(res)
+	}
+	return err
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ToQoz/gopwt/assert"
+)
+
+const (
+	unformatted = "contract C{uint x;}\n"
+	formatted   = "contract C {\n    uint x;\n}\n"
+)
+
+func TestFmt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "solzaemon")
+	assert.Require(t, err == nil)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "a.sol")
+	assert.Require(t, ioutil.WriteFile(path, []byte(unformatted), 0600) == nil)
+	other := filepath.Join(dir, "b.txt")
+	assert.Require(t, ioutil.WriteFile(other, []byte(unformatted), 0600) == nil)
+
+	var stdout, stderr bytes.Buffer
+	assert.OK(t, runFmt([]string{path}, nil, &stdout, &stderr) == 0)
+	assert.OK(t, stdout.String() == formatted)
+
+	stdout.Reset()
+	assert.OK(t, runFmt([]string{"-d", dir}, nil, &stdout, &stderr) == 0)
+	assert.OK(t, strings.HasPrefix(stdout.String(), "--- "+path+".orig\n+++ "+path+"\n@@ -1 +1,3 @@\n"))
+
+	stdout.Reset()
+	assert.OK(t, runFmt([]string{"-w", dir}, nil, &stdout, &stderr) == 0)
+	assert.OK(t, stdout.Len() == 0)
+	src, err := ioutil.ReadFile(path)
+	assert.Require(t, err == nil)
+	assert.OK(t, string(src) == formatted)
+	src, err = ioutil.ReadFile(other)
+	assert.Require(t, err == nil)
+	assert.OK(t, string(src) == unformatted)
+	assert.OK(t, stderr.Len() == 0)
+
+	// files with errors are reported, the others are still formatted
+	bad := filepath.Join(dir, "0.sol")
+	assert.Require(t, ioutil.WriteFile(bad, []byte("contract C {"), 0600) == nil)
+	assert.Require(t, ioutil.WriteFile(path, []byte(unformatted), 0600) == nil)
+	assert.OK(t, runFmt([]string{"-w", dir}, nil, &stdout, &stderr) == 2)
+	assert.OK(t, strings.HasPrefix(stderr.String(), bad+": "))
+	src, err = ioutil.ReadFile(path)
+	assert.Require(t, err == nil)
+	assert.OK(t, string(src) == formatted)
+}
+
+func TestFmt_stdin(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.OK(t, runFmt(nil, strings.NewReader(unformatted), &stdout, &stderr) == 0)
+	assert.OK(t, stdout.String() == formatted)
+
+	stdout.Reset()
+	assert.OK(t, runFmt([]string{"-w"}, strings.NewReader(unformatted), &stdout, &stderr) == 2)
+
+	stderr.Reset()
+	assert.OK(t, runFmt(nil, strings.NewReader("contract C {"), &stdout, &stderr) == 2)
+	assert.OK(t, strings.HasPrefix(stderr.String(), "<standard input>: "))
+}
@@ -6,234 +6,311 @@ import (
 
 	"github.com/blockchain-labs-org/solzaemon/ast"
 	"github.com/blockchain-labs-org/solzaemon/token"
+	"github.com/blockchain-labs-org/solzaemon/yul"
 )
 
 var unknownPosition = errors.New("unknown position")
 
-func definition(p *ast.Program, pos token.Pos) (token.Pos, error) {
-	ret, found, err := newDefinitionFinder(p).lookup(pos)
-	if err != nil {
-		return 0, err
+// definition returns the identifier declaring the name at pos.
+// pos may point anywhere within the name, including just after its end.
+func definition(p *ast.Program, pos token.Pos) (*ast.Ident, error) {
+	f := &definitionFinder{pos: pos, scope: newScope(nil), result: &definitionResult{}}
+	ast.Walk(f, p)
+	if f.result.err != nil {
+		return nil, f.result.err
 	}
-	if !found {
-		return 0, unknownPosition
+	if f.result.def == nil {
+		return nil, unknownPosition
 	}
 
-	return ret, nil
+	return f.result.def, nil
 }
 
 type scope struct {
 	outer   *scope
-	objects map[string]token.Pos
+	objects map[string]*ast.Ident
 }
 
-func (s *scope) lookup(name string) (token.Pos, bool) {
+func (s *scope) lookup(name string) (*ast.Ident, bool) {
 	ss := s
 	for {
-		if pos, ok := ss.objects[name]; ok {
-			return pos, true
+		if id, ok := ss.objects[name]; ok {
+			return id, true
 		}
 		if ss.outer == nil {
 			break
 		}
 		ss = ss.outer
 	}
-	return 0, false
+	return nil, false
 }
 
 func newScope(outer *scope) *scope {
-	return &scope{outer: outer, objects: map[string]token.Pos{}}
+	return &scope{outer: outer, objects: map[string]*ast.Ident{}}
 }
 
-type definitionFinder struct {
-	scope *scope
-	node  ast.Expr
+// declName returns the identifier declared by a declaration, or nil.
+func declName(decl ast.Decl) *ast.Ident {
+	switch d := decl.(type) {
+	case *ast.ContractPart:
+		return d.Name
+	case *ast.UserDefinedValueTypeDefinition:
+		return d.Name
+	case *ast.StateVariableDeclaration:
+		return d.Name
+	case *ast.FunctionDefinition:
+		return d.Name
+	case *ast.ModifierDefinition:
+		return d.Name
+	case *ast.EventDefinition:
+		return d.Name
+	case *ast.ErrorDefinition:
+		return d.Name
+	case *ast.StructDefinition:
+		return d.Name
+	case *ast.EnumDefinition:
+		return d.Name
+	}
+	return nil
 }
 
-func newDefinitionFinder(src ast.Expr) *definitionFinder {
-	return &definitionFinder{
-		scope: newScope(nil),
-		node:  src,
+// importNames returns the identifiers declared by an import directive: its
+// unit alias and the local names of its symbols.
+func importNames(imp *ast.ImportDirective) []*ast.Ident {
+	var names []*ast.Ident
+	if imp.Alias != nil {
+		names = append(names, imp.Alias)
+	}
+	for _, sym := range imp.Symbols {
+		if sym.Alias != nil {
+			names = append(names, sym.Alias)
+		} else {
+			names = append(names, sym.Name)
+		}
 	}
+	return names
 }
 
-func (f *definitionFinder) lookup(pos token.Pos) (token.Pos, bool, error) {
-	switch f.node.(type) {
-	case *ast.Program:
-		n := f.node.(*ast.Program)
-		for _, def := range n.ContractDefinition {
-			f.scope.objects[def.Name.Name] = def.Name.NamePos
-		}
+// definitionFinder is an ast.Visitor resolving the name at pos. It visits
+// the nodes in source order and declares names as they come into scope, so
+// that a name is resolved in the scope in effect at its position. Each
+// visitor carries the scope of the nodes it visits.
+type definitionFinder struct {
+	pos    token.Pos
+	scope  *scope
+	result *definitionResult // shared by all the visitors of a lookup
+}
 
-		for _, def := range n.ContractDefinition {
-			for _, inherit := range def.Inherits {
-				f.node = inherit
-				ret, found, err := f.lookup(pos)
-				if err != nil {
-					return 0, false, err
-				}
-				if found {
-					return ret, true, nil
-				}
-			}
-			f.node = def
+type definitionResult struct {
+	def *ast.Ident
+	err error
+}
 
-			f.scope = newScope(f.scope)
-			ret, found, err := f.lookup(pos)
-			if err != nil {
-				return 0, false, err
-			}
-			if found {
-				return ret, true, nil
-			}
-			f.scope = f.scope.outer
-		}
+// inner returns a visitor for the nodes of a new scope nested in the scope
+// of f.
+func (f *definitionFinder) inner() *definitionFinder {
+	return &definitionFinder{pos: f.pos, scope: newScope(f.scope), result: f.result}
+}
 
-		return 0, false, nil
-	case *ast.ContractPart:
-		n := f.node.(*ast.ContractPart)
+func (f *definitionFinder) declare(id *ast.Ident) {
+	f.scope.objects[id.Name] = id
+}
+
+// declareYul declares a Yul variable or function name.
+func (f *definitionFinder) declareYul(id *yul.Ident) {
+	f.declare(&ast.Ident{Name: id.Name, NamePos: id.NamePos})
+}
 
-		for _, def := range n.StateVariableDeclarations {
-			f.scope.objects[def.Name.Name] = def.Name.NamePos
+// declareYulFunctions declares the functions defined in a list of Yul
+// statements, which are visible in the whole block.
+func (f *definitionFinder) declareYulFunctions(list []yul.Stmt) {
+	for _, stmt := range list {
+		if def, ok := stmt.(*yul.FunctionDefinition); ok {
+			f.declareYul(def.Name)
 		}
-		for _, def := range n.FunctionDefinitions {
-			f.scope.objects[def.Name.Name] = def.Name.NamePos
+	}
+}
+
+// resolve looks up the name at pos, which is within a reference to name.
+func (f *definitionFinder) resolve(name string) {
+	if def, ok := f.scope.lookup(name); ok {
+		f.result.def = def
+		return
+	}
+	f.result.err = fmt.Errorf("definition of %s is not found in scope", name)
+}
+
+// walk walks each of nodes that is not nil.
+func (f *definitionFinder) walk(nodes ...ast.Node) {
+	for _, node := range nodes {
+		if node != nil {
+			ast.Walk(f, node)
 		}
+	}
+}
+
+func (f *definitionFinder) Visit(node ast.Node) ast.Visitor {
+	if node == nil || f.result.def != nil || f.result.err != nil {
+		return nil
+	}
 
-		for _, def := range n.StateVariableDeclarations {
-			f.node = def
-			ret, found, err := f.lookup(pos)
-			if err != nil {
-				return 0, false, err
+	switch n := node.(type) {
+	case *ast.Program:
+		for _, unit := range n.Units {
+			if name := declName(unit); name != nil {
+				f.declare(name)
 			}
-			if found {
-				return ret, true, nil
+			if imp, ok := unit.(*ast.ImportDirective); ok {
+				for _, name := range importNames(imp) {
+					f.declare(name)
+				}
 			}
 		}
-		for _, def := range n.FunctionDefinitions {
-			f.node = def
-			f.scope = newScope(f.scope)
-			ret, found, err := f.lookup(pos)
-			if err != nil {
-				return 0, false, err
-			}
-			if found {
-				return ret, true, nil
+	case *ast.PragmaDirective:
+		return nil
+	case *ast.ImportSymbol:
+		// the name of an aliased symbol refers to the imported unit
+		if n.Alias != nil {
+			f.walk(n.Alias)
+		} else {
+			f.walk(n.Name)
+		}
+		return nil
+	case *ast.ContractPart:
+		// base contracts are resolved outside of the contract
+		f.walk(n.Name)
+		for _, base := range n.Bases {
+			f.walk(base)
+		}
+		members := f.inner()
+		for _, member := range n.Members {
+			if name := declName(member); name != nil {
+				members.declare(name)
 			}
-			f.scope = f.scope.outer
 		}
-
-		return 0, false, nil
-	case *ast.StateVariableDeclaration:
-		n := f.node.(*ast.StateVariableDeclaration)
-		f.node = n.Name
-		ret, found, err := f.lookup(pos)
-		if err != nil {
-			return 0, false, err
+		for _, member := range n.Members {
+			members.walk(member)
 		}
-		if found {
-			return ret, true, nil
+		return nil
+	case *ast.FunctionDefinition, *ast.ModifierDefinition, *ast.EventDefinition,
+		*ast.ErrorDefinition, *ast.StructDefinition, *ast.UserDefinedValueTypeDefinition:
+		// parameters and fields are declared in the scope of their definition
+		return f.inner()
+	case *ast.EnumDefinition:
+		values := f.inner()
+		for _, value := range n.Values {
+			values.declare(value)
 		}
-		f.node = n.Rhs
-		return f.lookup(pos)
-	case *ast.FunctionDefinition:
-		n := f.node.(*ast.FunctionDefinition)
-		for _, stmt := range n.Block {
-			f.node = stmt
-			str, found, err := f.lookup(pos)
-			if err != nil {
-				return 0, false, err
+		return values
+	case *ast.Parameter:
+		f.walk(n.Typ)
+		if n.Name != nil {
+			f.declare(n.Name)
+			f.walk(n.Name)
+		}
+		return nil
+	case *ast.UserDefinedType:
+		// NOTE: only the first name of a qualified type is resolved
+		f.walk(n.Path[0])
+		return nil
+	case *ast.Mapping:
+		f.walk(n.Key, n.Value)
+		return nil
+	case *ast.FunctionType:
+		// parameter names of function types declare nothing
+		for _, list := range []*ast.ParameterList{n.Params, n.Returns} {
+			if list != nil {
+				for _, param := range list.List {
+					f.walk(param.Typ)
+				}
 			}
-			if found {
-				return str, found, nil
+		}
+		return nil
+	case *ast.BlockStmt, *ast.ForStmt:
+		return f.inner()
+	case *ast.VariableDeclarationStmt:
+		// the variables are in scope only after the value
+		f.walk(n.Value)
+		for _, v := range n.Vars {
+			if v != nil {
+				f.declare(v.Name)
+				f.walk(v)
 			}
 		}
-
-		return 0, false, nil
-	case *ast.CallExpr:
-		n := f.node.(*ast.CallExpr)
-		f.node = n.Fun
-		str, found, err := f.lookup(pos)
-		if err != nil {
-			return 0, false, err
+		return nil
+	case *ast.VariableDeclaration:
+		f.walk(n.Typ, n.Name)
+		return nil
+	case *ast.TryStmt:
+		f.walk(n.Call)
+		body := f.inner()
+		if n.Returns != nil {
+			body.walk(n.Returns)
 		}
-		if found {
-			return str, true, nil
+		body.walk(n.Body)
+		for _, clause := range n.Catches {
+			f.walk(clause)
 		}
-		for _, n := range n.Args {
-			f.node = n
-			str, found, err := f.lookup(pos)
-			if err != nil {
-				return 0, false, err
-			}
-			if found {
-				return str, true, nil
-			}
+		return nil
+	case *ast.CatchClause:
+		// the name of a clause, like Error, declares nothing
+		clause := f.inner()
+		if n.Params != nil {
+			clause.walk(n.Params)
+		}
+		clause.walk(n.Body)
+		return nil
+	case *ast.NamedArg:
+		// the names refer to parameters or options
+		f.walk(n.Value)
+		return nil
+	case *ast.BasicLit:
+		return nil
+	case *ast.Ident:
+		if n.Pos() <= f.pos && f.pos <= n.End() {
+			f.resolve(n.Name)
 		}
-		return 0, false, nil
-	case *ast.BinaryExpr:
-		n := f.node.(*ast.BinaryExpr)
-		if n.Op == token.ASSIGN {
-			switch n.X.(type) {
-			case *ast.Ident:
-				name := n.X.(*ast.Ident)
-				f.scope.objects[name.Name] = name.NamePos
+	case *yul.Block:
+		block := f.inner()
+		block.declareYulFunctions(n.List)
+		return block
+	case *yul.VariableDeclaration:
+		if n.Value != nil {
+			f.walk(n.Value)
+		}
+		for _, name := range n.Names {
+			f.declareYul(name)
+			f.walk(name)
+		}
+		return nil
+	case *yul.For:
+		// the variables of Init are visible in the rest of the loop
+		loop := f.inner()
+		loop.declareYulFunctions(n.Init.List)
+		for _, stmt := range n.Init.List {
+			loop.walk(stmt)
+		}
+		loop.walk(n.Cond, n.Post, n.Body)
+		return nil
+	case *yul.FunctionDefinition:
+		def := f.inner()
+		for _, params := range [][]*yul.Ident{n.Params, n.Returns} {
+			for _, param := range params {
+				def.declareYul(param)
 			}
 		}
-
-		f.node = n.X
-		str, found, err := f.lookup(pos)
-		if err != nil {
-			return 0, false, err
-		}
-		if found {
-			return str, true, nil
-		}
-		f.node = n.Y
-		return f.lookup(pos)
-	case *ast.ParenExpr:
-		n := f.node.(*ast.ParenExpr)
-		f.node = n.X
-		return f.lookup(pos)
-	case *ast.IndexExpr:
-		n := f.node.(*ast.IndexExpr)
-		f.node = n.X
-		str, found, err := f.lookup(pos)
-		if err != nil {
-			return 0, false, err
-		}
-		if found {
-			return str, true, nil
-		}
-		f.node = n.Index
-		return f.lookup(pos)
-	case *ast.SelectorExpr:
-		n := f.node.(*ast.SelectorExpr)
-		f.node = n.X
-		str, found, err := f.lookup(pos)
-		if err != nil {
-			return 0, false, err
-		}
-		if found {
-			return str, true, nil
-		}
-		f.node = n.Sel
-		return f.lookup(pos)
-	case *ast.Ident:
-		n := f.node.(*ast.Ident)
-		if n.NamePos == pos {
-			if ret, ok := f.scope.lookup(n.Name); ok {
-				return ret, true, nil
+		return def
+	case *yul.MemberAccess:
+		f.walk(n.X)
+		return nil
+	case *yul.Ident:
+		if n.Pos() <= f.pos && f.pos <= n.End() {
+			if _, ok := f.scope.lookup(n.Name); !ok && yul.LookupBuiltin(n.Name) != nil {
+				// builtins have no definition in the source
+				return nil
 			}
-			return 0, false, fmt.Errorf("definition of %s is not found in scope", n.Name)
+			f.resolve(n.Name)
 		}
-
-		return 0, false, nil
-	case *ast.BasicLit:
-		// ignore unnamed
-		return 0, false, nil
-	default:
-		fmt.Printf("%#v\n", f.node)
-		panic("unexpected node")
 	}
+	return f
 }
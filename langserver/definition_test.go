@@ -4,13 +4,20 @@ import (
 	"testing"
 
 	"github.com/ToQoz/gopwt/assert"
+	"github.com/blockchain-labs-org/solzaemon/ast"
 	"github.com/blockchain-labs-org/solzaemon/parser"
 	"github.com/blockchain-labs-org/solzaemon/token"
 )
 
+func parse(src string) (*token.File, *ast.Program, error) {
+	runes := []rune(src)
+	f := token.NewFileSet().AddFile("", -1, len(runes))
+	p, err := parser.Parse(f, runes)
+	return f, p, err
+}
+
 func TestDefinition_StateVarToStateVar(t *testing.T) {
-	f := token.NewFile()
-	got, err := parser.Parse(f, []rune(`pragma solidity ^0.4.23;
+	f, got, err := parse(`pragma solidity ^0.4.23;
 import "../token/ERC20/StandardToken.sol";
 
 contract SimpleToken is StandardToken {
@@ -23,17 +30,16 @@ contract SimpleToken is StandardToken {
 		totalSupply_ = INITIAL_SUPPLY;
 		balances[msg.sender] = INITIAL_SUPPLY;
 	}
-}`))
+}`)
 
-	def, err := definition(got, token.Pos(f.Offset(8, len(`	uint256 public constant INITIAL_SUPPLY = 10000 * (10 ** uint256(d`))))
+	def, err := definition(got, f.LineStart(8)+token.Pos(len(`	uint256 public constant INITIAL_SUPPLY = 10000 * (10 ** uint256(d`)-1))
 	assert.Require(t, err == nil)
-	assert.OK(t, f.Line(int(def)) == 7)
-	assert.OK(t, f.Character(int(def)) == len(`	uint8 public constant d`))
+	assert.OK(t, f.Position(def.NamePos).Line == 7)
+	assert.OK(t, f.Position(def.NamePos).Column == len(`	uint8 public constant d`))
 }
 
 func TestDefinitin_FuncBodyToStateVar(t *testing.T) {
-	f := token.NewFile()
-	got, err := parser.Parse(f, []rune(`pragma solidity ^0.4.23;
+	f, got, err := parse(`pragma solidity ^0.4.23;
 import "../token/ERC20/StandardToken.sol";
 
 contract SimpleToken is StandardToken {
@@ -46,17 +52,16 @@ contract SimpleToken is StandardToken {
 		totalSupply_ = INITIAL_SUPPLY;
 		balances[msg.sender] = INITIAL_SUPPLY;
 	}
-}`))
+}`)
 
-	def, err := definition(got, token.Pos(f.Offset(11, len(`		totalSupply_ = I`))))
+	def, err := definition(got, f.LineStart(11)+token.Pos(len(`		totalSupply_ = I`)-1))
 	assert.Require(t, err == nil)
-	assert.OK(t, f.Line(int(def)) == 8)
-	assert.OK(t, f.Character(int(def)) == len(`	uint256 public constant I`))
+	assert.OK(t, f.Position(def.NamePos).Line == 8)
+	assert.OK(t, f.Position(def.NamePos).Column == len(`	uint256 public constant I`))
 }
 
 func TestDefinition_FuncBodyToFuncDef(t *testing.T) {
-	f := token.NewFile()
-	got, err := parser.Parse(f, []rune(`pragma solidity ^0.4.23;
+	f, got, err := parse(`pragma solidity ^0.4.23;
 import "../token/ERC20/StandardToken.sol";
 
 contract SimpleToken is StandardToken {
@@ -76,17 +81,16 @@ contract SimpleToken is StandardToken {
 
 	function b() public {
 	}
-}`))
+}`)
 
-	def, err := definition(got, token.Pos(f.Offset(16, len(`		b`))))
+	def, err := definition(got, f.LineStart(16)+token.Pos(len(`		b`)-1))
 	assert.Require(t, err == nil)
-	assert.OK(t, f.Line(int(def)) == 19)
-	assert.OK(t, f.Character(int(def)) == len(`	function b`))
+	assert.OK(t, f.Position(def.NamePos).Line == 19)
+	assert.OK(t, f.Position(def.NamePos).Column == len(`	function b`))
 }
 
 func TestDefinition_FuncLocalVar(t *testing.T) {
-	f := token.NewFile()
-	got, err := parser.Parse(f, []rune(`pragma solidity ^0.4.23;
+	f, got, err := parse(`pragma solidity ^0.4.23;
 import "../token/ERC20/StandardToken.sol";
 
 contract SimpleToken is StandardToken {
@@ -96,37 +100,35 @@ contract SimpleToken is StandardToken {
 	uint256 public constant INITIAL_SUPPLY = 10000 * (10 ** uint256(decimals));
 
 	constructor() public {
-		totalSupply_ = INITIAL_SUPPLY;
-		totalSupply2_ = totalSupply_ * 2;
+		uint256 totalSupply_ = INITIAL_SUPPLY;
+		uint256 totalSupply2_ = totalSupply_ * 2;
 	}
-}`))
+}`)
 
-	def, err := definition(got, token.Pos(f.Offset(12, len(`		totalSupply2_ = t`))))
+	def, err := definition(got, f.LineStart(12)+token.Pos(len(`		uint256 totalSupply2_ = t`)-1))
 	assert.Require(t, err == nil)
-	assert.OK(t, f.Line(int(def)) == 11)
-	assert.OK(t, f.Character(int(def)) == len(`		t`))
+	assert.OK(t, f.Position(def.NamePos).Line == 11)
+	assert.OK(t, f.Position(def.NamePos).Column == len(`		uint256 t`))
 }
 
 func TestDefinition_Contract(t *testing.T) {
-	f := token.NewFile()
-	got, err := parser.Parse(f, []rune(`pragma solidity ^0.4.23;
+	f, got, err := parse(`pragma solidity ^0.4.23;
 import "../token/ERC20/StandardToken.sol";
 
 contract B is A {
 }
 
 contract A is StandardToken {
-}`))
+}`)
 
-	def, err := definition(got, token.Pos(f.Offset(4, len(`contract B is A`))))
+	def, err := definition(got, f.LineStart(4)+token.Pos(len(`contract B is A`)-1))
 	assert.Require(t, err == nil)
-	assert.OK(t, f.Line(int(def)) == 7)
-	assert.OK(t, f.Character(int(def)) == len(`contract A`))
+	assert.OK(t, f.Position(def.NamePos).Line == 7)
+	assert.OK(t, f.Position(def.NamePos).Column == len(`contract A`))
 }
 
 func TestDefinition_UndefinedVar(t *testing.T) {
-	f := token.NewFile()
-	got, err := parser.Parse(f, []rune(`pragma solidity ^0.4.23;
+	f, got, err := parse(`pragma solidity ^0.4.23;
 import "../token/ERC20/StandardToken.sol";
 
 contract SimpleToken is StandardToken {
@@ -139,18 +141,17 @@ contract SimpleToken is StandardToken {
 		totalSupply_ = INITIAL_SUPPLY;
 		totalSupply2_ = totalSupplyUndefined_ * 2;
 	}
-}`))
+}`)
 	assert.Require(t, err == nil)
 
 	{
-		_, err := definition(got, token.Pos(f.Offset(12, len(`		totalSupply2_ = t`))))
+		_, err := definition(got, f.LineStart(12)+token.Pos(len(`		totalSupply2_ = t`)-1))
 		assert.Require(t, err.Error() == `definition of totalSupplyUndefined_ is not found in scope`)
 	}
 }
 
 func TestDefinition_UnknownPosition(t *testing.T) {
-	f := token.NewFile()
-	got, err := parser.Parse(f, []rune(`pragma solidity ^0.4.23;
+	_, got, err := parse(`pragma solidity ^0.4.23;
 import "../token/ERC20/StandardToken.sol";
 
 contract SimpleToken is StandardToken {
@@ -163,11 +164,364 @@ contract SimpleToken is StandardToken {
 		totalSupply_ = INITIAL_SUPPLY;
 		totalSupply2_ = totalSupplyUndefined_ * 2;
 	}
-}`))
+}`)
 	assert.Require(t, err == nil)
 
 	{
-		_, err := definition(got, token.Pos(f.Offset(50, 5)))
+		_, err := definition(got, token.NoPos)
 		assert.Require(t, err == unknownPosition)
 	}
 }
+
+func TestDefinition_SyntaxError(t *testing.T) {
+	f, got, err := parse(`pragma solidity ^0.4.23;
+
+contract SimpleToken {
+	uint256 public constant INITIAL_SUPPLY = 10000 *;
+
+	constructor() public {
+		totalSupply_ = INITIAL_SUPPLY
+	}
+}`)
+	assert.Require(t, err != nil)
+
+	def, err := definition(got, f.LineStart(7)+token.Pos(len(`		totalSupply_ = I`)-1))
+	assert.Require(t, err == nil)
+	assert.OK(t, f.Position(def.NamePos).Line == 4)
+	assert.OK(t, f.Position(def.NamePos).Column == len(`	uint256 public constant I`))
+}
+
+func TestDefinition_UnfinishedAssembly(t *testing.T) {
+	f, got, err := parse(`contract A { uint x; function g() public { assembly } function f() public { x = 1; } }`)
+	assert.Require(t, err != nil)
+
+	def, err := definition(got, f.LineStart(1)+token.Pos(len(`contract A { uint x; function g() public { assembly } function f() public { `)))
+	assert.Require(t, err == nil)
+	assert.OK(t, f.Position(def.NamePos).Column == len(`contract A { uint x`))
+}
+
+func TestDefinition_FuncParam(t *testing.T) {
+	f, got, err := parse(`contract Token {
+	function transfer(address to, uint256 value) public returns (bool ok) {
+		balances[to] = value;
+		ok = true;
+	}
+}`)
+	assert.Require(t, err == nil)
+
+	def, err := definition(got, f.LineStart(3)+token.Pos(len(`		balances[to] = v`)-1))
+	assert.Require(t, err == nil)
+	assert.OK(t, f.Position(def.NamePos).Line == 2)
+	assert.OK(t, f.Position(def.NamePos).Column == len(`	function transfer(address to, uint256 v`))
+
+	def, err = definition(got, f.LineStart(4)+token.Pos(len(`		o`)-1))
+	assert.Require(t, err == nil)
+	assert.OK(t, f.Position(def.NamePos).Line == 2)
+	assert.OK(t, f.Position(def.NamePos).Column == len(`	function transfer(address to, uint256 value) public returns (bool o`))
+}
+
+func TestDefinition_ModifierArg(t *testing.T) {
+	f, got, err := parse(`contract A {
+}
+
+contract Token is A {
+	function burn(uint256 amount) public override(A) onlyAbove(amount) {
+	}
+}`)
+	assert.Require(t, err == nil)
+
+	def, err := definition(got, f.LineStart(5)+token.Pos(len(`	function burn(uint256 amount) public override(A) onlyAbove(a`)-1))
+	assert.Require(t, err == nil)
+	assert.OK(t, f.Position(def.NamePos).Line == 5)
+	assert.OK(t, f.Position(def.NamePos).Column == len(`	function burn(uint256 a`))
+
+	def, err = definition(got, f.LineStart(5)+token.Pos(len(`	function burn(uint256 amount) public override(A`)-1))
+	assert.Require(t, err == nil)
+	assert.OK(t, f.Position(def.NamePos).Line == 1)
+}
+
+func TestDefinition_ContractMembers(t *testing.T) {
+	f, got, err := parse(`contract Token {
+	struct Account {
+		uint256 balance;
+	}
+	enum State { Active, Frozen }
+	error Unauthorized(address sender);
+
+	modifier onlyOwner(address sender) {
+		_;
+	}
+
+	function transfer(Account memory account, State state) public onlyOwner(msg.sender) {
+	}
+}`)
+	assert.Require(t, err == nil)
+
+	tests := []struct {
+		line, column int
+		defLine      int
+	}{
+		{12, len(`	function transfer(A`), 2},
+		{12, len(`	function transfer(Account memory account, S`), 5},
+		{12, len(`	function transfer(Account memory account, State state) public o`), 8},
+		{8, len(`	modifier onlyOwner(address s`), 8},
+		{5, len(`	enum State { Active, F`), 5},
+		{6, len(`	error U`), 6},
+		{2, len(`	struct A`), 2},
+		{12, len(`	function t`), 12},
+	}
+	for _, tt := range tests {
+		def, err := definition(got, f.LineStart(tt.line)+token.Pos(tt.column-1))
+		assert.Require(t, err == nil)
+		assert.OK(t, f.Position(def.NamePos).Line == tt.defLine)
+	}
+}
+
+func TestDefinition_FileLevel(t *testing.T) {
+	f, got, err := parse(`type Price is uint128;
+uint256 constant MAX_SUPPLY = 1000;
+
+interface IToken {
+}
+
+library Math {
+	function max(Price a) internal pure returns (uint256 m) {
+		m = MAX_SUPPLY;
+	}
+}
+
+abstract contract Token is IToken {
+}`)
+	assert.Require(t, err == nil)
+
+	def, err := definition(got, f.LineStart(8)+token.Pos(len(`	function max(P`)-1))
+	assert.Require(t, err == nil)
+	assert.OK(t, f.Position(def.NamePos).Line == 1)
+
+	def, err = definition(got, f.LineStart(9)+token.Pos(len(`		m = M`)-1))
+	assert.Require(t, err == nil)
+	assert.OK(t, f.Position(def.NamePos).Line == 2)
+
+	def, err = definition(got, f.LineStart(13)+token.Pos(len(`abstract contract Token is I`)-1))
+	assert.Require(t, err == nil)
+	assert.OK(t, f.Position(def.NamePos).Line == 4)
+}
+
+func TestDefinition_Statements(t *testing.T) {
+	f, got, err := parse(`contract Token {
+	event Sent(uint256 amount);
+	uint256 total;
+
+	function f(uint256 n) public {
+		if (n > 0) {
+			total = n;
+		}
+		try this.g(n) returns (uint256 result) {
+			emit Sent(result);
+		} catch Error(string memory reason) {
+			revert(reason);
+		}
+	}
+}`)
+	assert.Require(t, err == nil)
+
+	tests := []struct {
+		line, column int
+		defLine      int
+		defColumn    int
+	}{
+		{7, len(`			t`), 3, len(`	uint256 t`)},
+		{7, len(`			total = n`), 5, len(`	function f(uint256 n`)},
+		{10, len(`			emit S`), 2, len(`	event S`)},
+		{10, len(`			emit Sent(r`), 9, len(`		try this.g(n) returns (uint256 r`)},
+		{12, len(`			revert(r`), 11, len(`		} catch Error(string memory r`)},
+	}
+	for _, tt := range tests {
+		def, err := definition(got, f.LineStart(tt.line)+token.Pos(tt.column-1))
+		assert.Require(t, err == nil)
+		assert.OK(t, f.Position(def.NamePos).Line == tt.defLine)
+		assert.OK(t, f.Position(def.NamePos).Column == tt.defColumn)
+	}
+}
+
+func TestDefinition_LocalVariables(t *testing.T) {
+	f, got, err := parse(`contract Token {
+	uint256 total;
+
+	function f() public returns (uint256) {
+		(uint256 a, , bool ok) = g();
+		uint256 total = total + a;
+		for (uint256 i = 0; i < total; i++) {
+			undeclared = i;
+		}
+		return ok ? total : 0;
+	}
+}`)
+	assert.Require(t, err == nil)
+
+	tests := []struct {
+		line, column int
+		defLine      int
+		defColumn    int
+	}{
+		{6, len(`		uint256 total = t`), 2, len(`	uint256 t`)},
+		{6, len(`		uint256 total = total + a`), 5, len(`		(uint256 a`)},
+		{7, len(`		for (uint256 i = 0; i < t`), 6, len(`		uint256 t`)},
+		{8, len(`			undeclared = i`), 7, len(`		for (uint256 i`)},
+		{10, len(`		return o`), 5, len(`		(uint256 a, , bool o`)},
+		{10, len(`		return ok ? t`), 6, len(`		uint256 t`)},
+	}
+	for _, tt := range tests {
+		def, err := definition(got, f.LineStart(tt.line)+token.Pos(tt.column-1))
+		assert.Require(t, err == nil)
+		assert.OK(t, f.Position(def.NamePos).Line == tt.defLine)
+		assert.OK(t, f.Position(def.NamePos).Column == tt.defColumn)
+	}
+
+	// assignments do not declare names
+	_, err = definition(got, f.LineStart(8)+token.Pos(len(`			u`)-1))
+	assert.OK(t, err.Error() == "definition of undeclared is not found in scope")
+}
+
+func TestDefinition_TypeNames(t *testing.T) {
+	f, got, err := parse(`contract Token {
+	struct Info { uint256 balance; }
+	mapping(address => Info[]) infos;
+	function (Info memory) external callback;
+
+	function f(Token other) public {
+		Info[] storage list = infos[msg.sender];
+	}
+}`)
+	assert.Require(t, err == nil)
+
+	tests := []struct {
+		line, column int
+		defLine      int
+		defColumn    int
+	}{
+		{3, len(`	mapping(address => I`), 2, len(`	struct I`)},
+		{4, len(`	function (I`), 2, len(`	struct I`)},
+		{6, len(`	function f(T`), 1, len(`contract T`)},
+		{7, len(`		I`), 2, len(`	struct I`)},
+		{7, len(`		Info[] storage list = i`), 3, len(`	mapping(address => Info[]) i`)},
+	}
+	for _, tt := range tests {
+		def, err := definition(got, f.LineStart(tt.line)+token.Pos(tt.column-1))
+		assert.Require(t, err == nil)
+		assert.OK(t, f.Position(def.NamePos).Line == tt.defLine)
+		assert.OK(t, f.Position(def.NamePos).Column == tt.defColumn)
+	}
+
+	// elementary types have no definition
+	_, err = definition(got, f.LineStart(3)+token.Pos(len(`	mapping(a`)-1))
+	assert.OK(t, err == unknownPosition)
+}
+
+func TestDefinition_ImportAliases(t *testing.T) {
+	f, got, err := parse(`import "./a.sol" as A;
+import {Token as T, Math} from "./b.sol";
+
+contract C is T {
+	function f() public {
+		A.g(Math.max(1, 2));
+	}
+}`)
+	assert.Require(t, err == nil)
+
+	tests := []struct {
+		line, column int
+		defLine      int
+		defColumn    int
+	}{
+		{4, len(`contract C is T`), 2, len(`import {Token as T`)},
+		{6, len(`		A`), 1, len(`import "./a.sol" as A`)},
+		{6, len(`		A.g(M`), 2, len(`import {Token as T, M`)},
+		{2, len(`import {Token as T, M`), 2, len(`import {Token as T, M`)},
+	}
+	for _, tt := range tests {
+		def, err := definition(got, f.LineStart(tt.line)+token.Pos(tt.column-1))
+		assert.Require(t, err == nil)
+		assert.OK(t, f.Position(def.NamePos).Line == tt.defLine)
+		assert.OK(t, f.Position(def.NamePos).Column == tt.defColumn)
+	}
+}
+
+func TestDefinition_Assembly(t *testing.T) {
+	f, got, err := parse(`contract C {
+	uint256 total;
+
+	function f(uint256 x) public returns (uint256 r) {
+		assembly {
+			let y := add(x, sload(total.slot))
+			function double(a) -> b { b := mul(a, 2) }
+			r := double(y)
+			sstore(0, twice(y))
+		}
+	}
+}`)
+	assert.Require(t, err == nil)
+
+	tests := []struct {
+		line, column int
+		defLine      int
+		defColumn    int
+	}{
+		{6, len(`			let y := add(x`), 4, len(`	function f(uint256 x`)},
+		{6, len(`			let y := add(x, sload(t`), 2, len(`	uint256 t`)},
+		{7, len(`			function double(a) -> b { b := mul(a`), 7, len(`			function double(a`)},
+		{8, len(`			r`), 4, len(`	function f(uint256 x) public returns (uint256 r`)},
+		{8, len(`			r := d`), 7, len(`			function d`)},
+		{8, len(`			r := double(y`), 6, len(`			let y`)},
+	}
+	for _, tt := range tests {
+		def, err := definition(got, f.LineStart(tt.line)+token.Pos(tt.column-1))
+		assert.Require(t, err == nil)
+		assert.OK(t, f.Position(def.NamePos).Line == tt.defLine)
+		assert.OK(t, f.Position(def.NamePos).Column == tt.defColumn)
+	}
+
+	{
+		_, err := definition(got, f.LineStart(6)+token.Pos(len(`			let y := a`)-1))
+		assert.OK(t, err == unknownPosition)
+	}
+	{
+		_, err := definition(got, f.LineStart(9)+token.Pos(len(`			sstore(0, t`)-1))
+		assert.OK(t, err.Error() == `definition of twice is not found in scope`)
+	}
+}
+
+func TestDefinition_ExprSuffixes(t *testing.T) {
+	f, got, err := parse(`contract D {}
+
+contract C {
+	function f(address a, uint256 v, bytes calldata data) public {
+		a.call{value: v}(data[4:v]);
+		uint8[2] memory xs = [type(uint8).max, uint8(v)];
+		D d = new D{salt: bytes32(v)}();
+		g({amount: v});
+	}
+}`)
+	assert.Require(t, err == nil)
+
+	tests := []struct {
+		line, column int
+		defLine      int
+		defColumn    int
+	}{
+		{5, len(`		a`), 4, len(`	function f(address a`)},
+		{5, len(`		a.call{value: v`), 4, len(`	function f(address a, uint256 v`)},
+		{5, len(`		a.call{value: v}(d`), 4, len(`	function f(address a, uint256 v, bytes calldata d`)},
+		{5, len(`		a.call{value: v}(data[4:v`), 4, len(`	function f(address a, uint256 v`)},
+		{6, len(`		uint8[2] memory xs = [type(uint8).max, uint8(v`), 4, len(`	function f(address a, uint256 v`)},
+		{7, len(`		D d = new D`), 1, len(`contract D`)},
+		{7, len(`		D d = new D{salt: bytes32(v`), 4, len(`	function f(address a, uint256 v`)},
+		{8, len(`		g({amount: v`), 4, len(`	function f(address a, uint256 v`)},
+	}
+	for _, tt := range tests {
+		def, err := definition(got, f.LineStart(tt.line)+token.Pos(tt.column-1))
+		assert.Require(t, err == nil)
+		assert.OK(t, f.Position(def.NamePos).Line == tt.defLine)
+		assert.OK(t, f.Position(def.NamePos).Column == tt.defColumn)
+	}
+}
@@ -0,0 +1,57 @@
+package langserver
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/blockchain-labs-org/solzaemon/diff"
+	"github.com/blockchain-labs-org/solzaemon/printer"
+	protocol "github.com/sourcegraph/go-langserver/pkg/lsp"
+)
+
+// formatting returns the edits formatting contents, each replacing whole
+// lines. If rng is not nil, only the edits touching its lines are
+// returned.
+func formatting(contents []byte, enc positionEncoding, rng *protocol.Range) ([]protocol.TextEdit, error) {
+	formatted, err := printer.Source(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := diff.Lines(string(contents))
+	// offsets[i] is the rune offset of lines[i]
+	offsets := make([]int, len(lines)+1)
+	for i, line := range lines {
+		offsets[i+1] = offsets[i] + utf8.RuneCountInString(line)
+	}
+	src := []rune(string(contents))
+
+	edits := []protocol.TextEdit{}
+	for _, e := range diff.Edits(lines, diff.Lines(string(formatted))) {
+		if rng != nil && !touches(e, rng) {
+			continue
+		}
+		edits = append(edits, protocol.TextEdit{
+			Range: protocol.Range{
+				Start: enc.position(src, offsets[e.Start]),
+				End:   enc.position(src, offsets[e.End]),
+			},
+			NewText: strings.Join(e.New, ""),
+		})
+	}
+	return edits, nil
+}
+
+// touches reports whether e replaces a line of rng or inserts lines within
+// it.
+func touches(e diff.Edit, rng *protocol.Range) bool {
+	first, last := rng.Start.Line, rng.End.Line
+	if rng.End.Character == 0 && last > first {
+		// the range ends at the end of the previous line
+		last--
+	}
+	if e.Start == e.End {
+		return first < e.Start && e.Start <= last
+	}
+	return e.Start <= last && first < e.End
+}
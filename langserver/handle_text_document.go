@@ -1,7 +1,6 @@
 package langserver
 
 import (
-	"bytes"
 	"fmt"
 
 	"github.com/blockchain-labs-org/solzaemon/parser"
@@ -15,29 +14,59 @@ func (h *Handler) handleTextDocumentDefinition(params protocol.TextDocumentPosit
 		return nil, fmt.Errorf("received textDocument/definition for unknown file %q", params.TextDocument.URI)
 	}
 
-	f := token.NewFile()
-	p, err := parser.Parse(f, []rune(string(contents)))
+	src := []rune(string(contents))
+	f := token.NewFileSet().AddFile(string(params.TextDocument.URI), -1, len(src))
+	// Syntax errors are ignored; definitions are looked up in the
+	// best-effort tree.
+	p, _ := parser.Parse(f, src)
+
+	enc := h.getEncoding()
+	offset, err := enc.offset(src, params.Position)
 	if err != nil {
-		panic(err) // FIXME
+		return nil, fmt.Errorf("received textDocument/definition for %s: %v", params.TextDocument.URI, err)
+	}
+	d, err := definition(p, f.Pos(offset))
+	if err == unknownPosition {
+		return []protocol.Location{}, nil
 	}
-
-	d, err := definition(p, token.Pos(f.Offset(params.Position.Line, params.Position.Character)))
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("received textDocument/definition for %s: %v", params.TextDocument.URI, err)
 	}
 	loc := protocol.Location{
-		URI: "code",
+		URI: params.TextDocument.URI,
 		Range: protocol.Range{
-			Start: protocol.Position{
-				Line:      f.Line(int(d)),
-				Character: f.Character(int(d)),
-			},
+			Start: enc.position(src, f.Offset(d.NamePos)),
+			End:   enc.position(src, f.Offset(d.End())),
 		},
 	}
 	locs := []protocol.Location{loc}
 	return locs, nil
 }
 
+func (h *Handler) handleTextDocumentFormatting(params protocol.DocumentFormattingParams) ([]protocol.TextEdit, error) {
+	contents, found := h.getDoc(params.TextDocument.URI)
+	if !found {
+		return nil, fmt.Errorf("received textDocument/formatting for unknown file %q", params.TextDocument.URI)
+	}
+	edits, err := formatting(contents, h.getEncoding(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("received textDocument/formatting for %s: %v", params.TextDocument.URI, err)
+	}
+	return edits, nil
+}
+
+func (h *Handler) handleTextDocumentRangeFormatting(params protocol.DocumentRangeFormattingParams) ([]protocol.TextEdit, error) {
+	contents, found := h.getDoc(params.TextDocument.URI)
+	if !found {
+		return nil, fmt.Errorf("received textDocument/rangeFormatting for unknown file %q", params.TextDocument.URI)
+	}
+	edits, err := formatting(contents, h.getEncoding(), &params.Range)
+	if err != nil {
+		return nil, fmt.Errorf("received textDocument/rangeFormatting for %s: %v", params.TextDocument.URI, err)
+	}
+	return edits, nil
+}
+
 func (h *Handler) handleTextDocumentDidOpen(params protocol.DidOpenTextDocumentParams) (protocol.DocumentURI, error) {
 	h.setDocString(params.TextDocument.URI, params.TextDocument.Text)
 	return params.TextDocument.URI, nil
@@ -52,55 +81,35 @@ func (h *Handler) handleTextDocumentDidChange(params protocol.DidChangeTextDocum
 	// The content changes descibe single state changes to the document.
 	// So if there are two content changes c1 and c2 for a document in state S10 then c1 move the document to S11 and c2 to S12.
 	// https://github.com/Microsoft/language-server-protocol/commit/fcb32f98317a1d37c798ca7309bb42ad8749d81d
+	enc := h.getEncoding()
+	src := []rune(string(contents))
 	for _, change := range params.ContentChanges {
-		sp := change.Range.Start
-		ep := change.Range.End
-
-		line := 0
-		col := 0
-		soffset := 0
-		eoffset := 0
-		startFound := false
-		rangelen := int(change.RangeLength)
-		for _, b := range contents {
-			if line == sp.Line && col == sp.Character {
-				startFound = true
-			}
-			if !startFound {
-				if (line == sp.Line && col > sp.Character) || line > sp.Line {
-					return params.TextDocument.URI, fmt.Errorf("received textDocument/didChange for invalid start position %#v on %s", sp, params.TextDocument.URI)
-				}
-				soffset++
-			} else {
-				if rangelen != 0 || (line == ep.Line && col == ep.Character) {
-					eoffset += soffset + rangelen
-					if soffset < 0 || eoffset >= len(contents) || eoffset < soffset {
-						return params.TextDocument.URI, fmt.Errorf("received textDocument/didChange for out of range %#v on %s", change.Range, params.TextDocument.URI)
-					}
-
-					b := &bytes.Buffer{}
-					b.Grow(soffset + len(change.Text) + len(contents) - eoffset)
-					b.Write(contents[:soffset])
-					b.WriteString(change.Text)
-					b.Write(contents[eoffset:])
-					contents = b.Bytes()
-					break
-				}
-				if (line == ep.Line && col > ep.Character) || line > ep.Line {
-					return params.TextDocument.URI, fmt.Errorf("received textDocument/didChange for invalid end position %#v on %s", ep, params.TextDocument.URI)
-				}
-
-				eoffset++
-			}
+		if change.Range == nil {
+			src = []rune(change.Text)
+			continue
+		}
 
-			if b == '\n' {
-				line++
-				col = 0
-			} else {
-				col++
-			}
+		start, err := enc.offset(src, change.Range.Start)
+		if err != nil {
+			return params.TextDocument.URI, fmt.Errorf("received textDocument/didChange for invalid start position on %s: %v", params.TextDocument.URI, err)
 		}
+		// RangeLength is deprecated; the end position is authoritative.
+		end, err := enc.offset(src, change.Range.End)
+		if err != nil {
+			return params.TextDocument.URI, fmt.Errorf("received textDocument/didChange for invalid end position on %s: %v", params.TextDocument.URI, err)
+		}
+		if end < start {
+			return params.TextDocument.URI, fmt.Errorf("received textDocument/didChange for out of range %#v on %s", change.Range, params.TextDocument.URI)
+		}
+
+		text := []rune(change.Text)
+		b := make([]rune, 0, len(src)-(end-start)+len(text))
+		b = append(b, src[:start]...)
+		b = append(b, text...)
+		b = append(b, src[end:]...)
+		src = b
 	}
+	contents = []byte(string(src))
 
 	h.setDoc(params.TextDocument.URI, contents)
 	return params.TextDocument.URI, nil
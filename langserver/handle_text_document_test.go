@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/ToQoz/gopwt/assert"
+	"github.com/blockchain-labs-org/solzaemon/printer"
 	protocol "github.com/sourcegraph/go-langserver/pkg/lsp"
 )
 
@@ -29,15 +30,83 @@ contract SimpleToken is StandardToken {
 			URI: "code",
 		},
 		Position: protocol.Position{
-			Line:      11,
-			Character: 18,
+			Line:      10,
+			Character: 17,
 		},
 	}
 	locs, err := handler.handleTextDocumentDefinition(params)
 	assert.Require(t, err == nil)
 	assert.Require(t, len(locs) == 1)
 	assert.OK(t, locs[0].URI == "code")
-	assert.OK(t, reflect.DeepEqual(locs[0].Range.Start, protocol.Position{Line: 8, Character: 26}))
+	assert.OK(t, reflect.DeepEqual(locs[0].Range.Start, protocol.Position{Line: 7, Character: 25}))
+	assert.OK(t, reflect.DeepEqual(locs[0].Range.End, protocol.Position{Line: 7, Character: 39}))
+}
+
+func TestHandleTextDocumentDefinition_RoundTrip(t *testing.T) {
+	uri := protocol.DocumentURI("file:///home/user/Token.sol")
+	handler := NewHandler()
+	handler.Docs[uri] = []byte(`pragma solidity ^0.4.23;
+
+contract Token {
+	string public constant name = unicode"😃 Token"; uint8 public constant decimals = 18;
+
+	constructor() public {
+		supply = decimals;
+	}
+}`)
+	tests := []struct {
+		encoding positionEncoding
+		start    protocol.Position
+		end      protocol.Position
+	}{
+		{utf8Encoding, protocol.Position{Line: 3, Character: 74}, protocol.Position{Line: 3, Character: 82}},
+		{utf16Encoding, protocol.Position{Line: 3, Character: 72}, protocol.Position{Line: 3, Character: 80}},
+		{utf32Encoding, protocol.Position{Line: 3, Character: 71}, protocol.Position{Line: 3, Character: 79}},
+	}
+	for _, tt := range tests {
+		handler.encoding = tt.encoding
+		// Editors report the cursor on any character of the name or just after it.
+		for _, character := range []int{11, 14, 19} {
+			locs, err := handler.handleTextDocumentDefinition(protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+				Position:     protocol.Position{Line: 6, Character: character},
+			})
+			assert.Require(t, err == nil)
+			assert.Require(t, len(locs) == 1)
+			assert.OK(t, locs[0].URI == uri)
+			assert.OK(t, reflect.DeepEqual(locs[0].Range, protocol.Range{Start: tt.start, End: tt.end}), string(tt.encoding))
+		}
+
+		// A definition resolves to itself.
+		for _, pos := range []protocol.Position{tt.start, tt.end} {
+			locs, err := handler.handleTextDocumentDefinition(protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+				Position:     pos,
+			})
+			assert.Require(t, err == nil)
+			assert.Require(t, len(locs) == 1)
+			assert.OK(t, reflect.DeepEqual(locs[0].Range, protocol.Range{Start: tt.start, End: tt.end}), string(tt.encoding))
+		}
+	}
+}
+
+func TestHandleTextDocumentDefinition_NoDefinition(t *testing.T) {
+	handler := NewHandler()
+	handler.Docs["code"] = []byte(`contract A {
+	uint a = 1;
+}`)
+	locs, err := handler.handleTextDocumentDefinition(protocol.TextDocumentPositionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: "code"},
+		Position:     protocol.Position{Line: 2, Character: 0},
+	})
+	assert.Require(t, err == nil)
+	assert.OK(t, len(locs) == 0)
+
+	_, err = handler.handleTextDocumentDefinition(protocol.TextDocumentPositionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: "code"},
+		Position:     protocol.Position{Line: 5, Character: 0},
+	})
+	assert.OK(t, err != nil)
 }
 
 func TestHandleTextDocumentDidOpen(t *testing.T) {
@@ -98,6 +167,7 @@ func TestHandleTextDocumentDidChange(t *testing.T) {
 			protocol.TextDocumentContentChangeEvent{
 				Range: &protocol.Range{
 					Start: protocol.Position{Line: 2, Character: 1},
+					End:   protocol.Position{Line: 2, Character: 3},
 				},
 				RangeLength: 2,
 				Text:        "myos",
@@ -112,3 +182,212 @@ func TestHandleTextDocumentDidChange(t *testing.T) {
 	myos.Exit(0)
 }`)
 }
+
+func TestHandleTextDocumentDidChange_MultiLine(t *testing.T) {
+	handler := NewHandler()
+	handler.Docs["code"] = []byte("ab\ncd\nef\ngh")
+	params := protocol.DidChangeTextDocumentParams{
+		TextDocument: protocol.VersionedTextDocumentIdentifier{
+			TextDocumentIdentifier: protocol.TextDocumentIdentifier{
+				URI: "code",
+			},
+		},
+		ContentChanges: []protocol.TextDocumentContentChangeEvent{
+			protocol.TextDocumentContentChangeEvent{
+				Range: &protocol.Range{
+					Start: protocol.Position{Line: 0, Character: 1},
+					End:   protocol.Position{Line: 1, Character: 1},
+				},
+				RangeLength: 3,
+				Text:        "X",
+			},
+			protocol.TextDocumentContentChangeEvent{
+				Range: &protocol.Range{
+					Start: protocol.Position{Line: 1, Character: 1},
+					End:   protocol.Position{Line: 2, Character: 0},
+				},
+				RangeLength: 3,
+				Text:        "Y\nZ",
+			},
+		},
+	}
+	_, err := handler.handleTextDocumentDidChange(params)
+	assert.Require(t, err == nil)
+	assert.OK(t, string(handler.Docs["code"]) == "aXd\neY\nZgh")
+}
+
+func TestHandleTextDocumentDidChange_NonASCII(t *testing.T) {
+	for _, tt := range []struct {
+		encoding  positionEncoding
+		character int
+	}{
+		{utf8Encoding, len("// 😃é ")},
+		{utf16Encoding, 7},
+		{utf32Encoding, 6},
+	} {
+		handler := NewHandler()
+		handler.encoding = tt.encoding
+		handler.Docs["code"] = []byte("// 😃é x\ncontract A {}")
+		params := protocol.DidChangeTextDocumentParams{
+			TextDocument: protocol.VersionedTextDocumentIdentifier{
+				TextDocumentIdentifier: protocol.TextDocumentIdentifier{
+					URI: "code",
+				},
+			},
+			ContentChanges: []protocol.TextDocumentContentChangeEvent{
+				protocol.TextDocumentContentChangeEvent{
+					Range: &protocol.Range{
+						Start: protocol.Position{Line: 0, Character: tt.character},
+						End:   protocol.Position{Line: 0, Character: tt.character + 1},
+					},
+					Text: "y",
+				},
+			},
+		}
+		_, err := handler.handleTextDocumentDidChange(params)
+		assert.Require(t, err == nil)
+		assert.OK(t, string(handler.Docs["code"]) == "// 😃é y\ncontract A {}", string(tt.encoding))
+	}
+}
+
+func TestHandleTextDocumentDidChange_FullText(t *testing.T) {
+	handler := NewHandler()
+	handler.Docs["code"] = []byte("old")
+	params := protocol.DidChangeTextDocumentParams{
+		TextDocument: protocol.VersionedTextDocumentIdentifier{
+			TextDocumentIdentifier: protocol.TextDocumentIdentifier{
+				URI: "code",
+			},
+		},
+		ContentChanges: []protocol.TextDocumentContentChangeEvent{
+			protocol.TextDocumentContentChangeEvent{Text: "new"},
+		},
+	}
+	_, err := handler.handleTextDocumentDidChange(params)
+	assert.Require(t, err == nil)
+	assert.OK(t, string(handler.Docs["code"]) == "new")
+}
+
+func TestHandleTextDocumentDidChange_InvalidPosition(t *testing.T) {
+	handler := NewHandler()
+	handler.Docs["code"] = []byte("one line")
+	params := protocol.DidChangeTextDocumentParams{
+		TextDocument: protocol.VersionedTextDocumentIdentifier{
+			TextDocumentIdentifier: protocol.TextDocumentIdentifier{
+				URI: "code",
+			},
+		},
+		ContentChanges: []protocol.TextDocumentContentChangeEvent{
+			protocol.TextDocumentContentChangeEvent{
+				Range: &protocol.Range{
+					Start: protocol.Position{Line: 3, Character: 0},
+					End:   protocol.Position{Line: 3, Character: 0},
+				},
+				Text: "x",
+			},
+		},
+	}
+	_, err := handler.handleTextDocumentDidChange(params)
+	assert.OK(t, err != nil)
+	assert.OK(t, string(handler.Docs["code"]) == "one line")
+}
+
+// applyEdits applies edits to the document uri of handler the way clients
+// do, from the last to the first.
+func applyEdits(t *testing.T, handler *Handler, uri protocol.DocumentURI, edits []protocol.TextEdit) {
+	params := protocol.DidChangeTextDocumentParams{
+		TextDocument: protocol.VersionedTextDocumentIdentifier{
+			TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: uri},
+		},
+	}
+	for i := len(edits) - 1; i >= 0; i-- {
+		rng := edits[i].Range
+		params.ContentChanges = append(params.ContentChanges, protocol.TextDocumentContentChangeEvent{Range: &rng, Text: edits[i].NewText})
+	}
+	_, err := handler.handleTextDocumentDidChange(params)
+	assert.Require(t, err == nil)
+}
+
+const unformatted = `pragma solidity ^0.8.0;
+
+contract A {
+    uint a = 1; // 😃
+
+  function f() public {
+      a+=1;
+  }
+
+    function g() public {
+    a -= 1;
+    }
+}
+`
+
+func TestHandleTextDocumentFormatting(t *testing.T) {
+	formatted, err := printer.Source([]byte(unformatted))
+	assert.Require(t, err == nil)
+
+	for _, encoding := range []positionEncoding{utf8Encoding, utf16Encoding, utf32Encoding} {
+		handler := NewHandler()
+		handler.encoding = encoding
+		handler.Docs["code"] = []byte(unformatted)
+		edits, err := handler.handleTextDocumentFormatting(protocol.DocumentFormattingParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: "code"},
+		})
+		assert.Require(t, err == nil)
+		assert.OK(t, len(edits) == 2, string(encoding))
+		applyEdits(t, handler, "code", edits)
+		assert.OK(t, string(handler.Docs["code"]) == string(formatted), string(encoding))
+
+		// formatting again changes nothing
+		edits, err = handler.handleTextDocumentFormatting(protocol.DocumentFormattingParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: "code"},
+		})
+		assert.Require(t, err == nil)
+		assert.OK(t, edits != nil && len(edits) == 0)
+	}
+}
+
+func TestHandleTextDocumentFormatting_SyntaxError(t *testing.T) {
+	handler := NewHandler()
+	handler.Docs["code"] = []byte("contract A {")
+	_, err := handler.handleTextDocumentFormatting(protocol.DocumentFormattingParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: "code"},
+	})
+	assert.OK(t, err != nil)
+
+	_, err = handler.handleTextDocumentFormatting(protocol.DocumentFormattingParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: "unknown"},
+	})
+	assert.OK(t, err != nil)
+}
+
+func TestHandleTextDocumentRangeFormatting(t *testing.T) {
+	handler := NewHandler()
+	handler.Docs["code"] = []byte(unformatted)
+	// the lines of f, ending at the start of the line after it
+	edits, err := handler.handleTextDocumentRangeFormatting(protocol.DocumentRangeFormattingParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: "code"},
+		Range: protocol.Range{
+			Start: protocol.Position{Line: 5, Character: 0},
+			End:   protocol.Position{Line: 8, Character: 0},
+		},
+	})
+	assert.Require(t, err == nil)
+	assert.OK(t, len(edits) == 1)
+	applyEdits(t, handler, "code", edits)
+	assert.OK(t, string(handler.Docs["code"]) == `pragma solidity ^0.8.0;
+
+contract A {
+    uint a = 1; // 😃
+
+    function f() public {
+        a += 1;
+    }
+
+    function g() public {
+    a -= 1;
+    }
+}
+`)
+}
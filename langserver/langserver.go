@@ -13,12 +13,15 @@ import (
 type Handler struct {
 	Mu   sync.Mutex
 	Docs map[protocol.DocumentURI][]byte
+
+	encoding positionEncoding
 }
 
 func NewHandler() *Handler {
 	return &Handler{
-		Mu:   sync.Mutex{},
-		Docs: map[protocol.DocumentURI][]byte{},
+		Mu:       sync.Mutex{},
+		Docs:     map[protocol.DocumentURI][]byte{},
+		encoding: utf16Encoding,
 	}
 }
 
@@ -30,7 +33,11 @@ func (h *Handler) Handle(ctx context.Context, conn jsonrpc2.JSONRPC2, req *jsonr
 	}()
 	switch req.Method {
 	case "initialize":
-		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeMethodNotFound, Message: fmt.Sprintf("method not supported: %s", req.Method)}
+		var params initializeParams
+		if err := json.Unmarshal(*req.Params, &params); err != nil {
+			return nil, err
+		}
+		return h.handleInitialize(params)
 	case "initialized":
 		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeMethodNotFound, Message: fmt.Sprintf("method not supported: %s", req.Method)}
 	case "shutdown":
@@ -60,7 +67,17 @@ func (h *Handler) Handle(ctx context.Context, conn jsonrpc2.JSONRPC2, req *jsonr
 	case "textDocument/signatureHelp":
 		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeMethodNotFound, Message: fmt.Sprintf("method not supported: %s", req.Method)}
 	case "textDocument/formatting":
-		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeMethodNotFound, Message: fmt.Sprintf("method not supported: %s", req.Method)}
+		var params protocol.DocumentFormattingParams
+		if err := json.Unmarshal(*req.Params, &params); err != nil {
+			return nil, err
+		}
+		return h.handleTextDocumentFormatting(params)
+	case "textDocument/rangeFormatting":
+		var params protocol.DocumentRangeFormattingParams
+		if err := json.Unmarshal(*req.Params, &params); err != nil {
+			return nil, err
+		}
+		return h.handleTextDocumentRangeFormatting(params)
 	case "workspace/symbol":
 		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeMethodNotFound, Message: fmt.Sprintf("method not supported: %s", req.Method)}
 	case "workspace/xreferences":
@@ -86,6 +103,63 @@ func (h *Handler) Handle(ctx context.Context, conn jsonrpc2.JSONRPC2, req *jsonr
 	}
 }
 
+// initializeParams holds the parts of InitializeParams that are not in
+// protocol.InitializeParams yet.
+type initializeParams struct {
+	Capabilities struct {
+		General struct {
+			PositionEncodings []positionEncoding `json:"positionEncodings"`
+		} `json:"general"`
+	} `json:"capabilities"`
+}
+
+type serverCapabilities struct {
+	protocol.ServerCapabilities
+	PositionEncoding positionEncoding `json:"positionEncoding,omitempty"`
+}
+
+type initializeResult struct {
+	Capabilities serverCapabilities `json:"capabilities"`
+}
+
+func (h *Handler) handleInitialize(params initializeParams) (*initializeResult, error) {
+	// The client lists encodings in decreasing order of preference and
+	// UTF-16 must always be supported.
+	encoding := utf16Encoding
+	for _, enc := range params.Capabilities.General.PositionEncodings {
+		if enc.supported() {
+			encoding = enc
+			break
+		}
+	}
+	h.setEncoding(encoding)
+
+	kind := protocol.TDSKIncremental
+	return &initializeResult{
+		Capabilities: serverCapabilities{
+			ServerCapabilities: protocol.ServerCapabilities{
+				TextDocumentSync:                &protocol.TextDocumentSyncOptionsOrKind{Kind: &kind},
+				DefinitionProvider:              true,
+				DocumentFormattingProvider:      true,
+				DocumentRangeFormattingProvider: true,
+			},
+			PositionEncoding: encoding,
+		},
+	}, nil
+}
+
+func (h *Handler) setEncoding(enc positionEncoding) {
+	h.Mu.Lock()
+	defer h.Mu.Unlock()
+	h.encoding = enc
+}
+
+func (h *Handler) getEncoding() positionEncoding {
+	h.Mu.Lock()
+	defer h.Mu.Unlock()
+	return h.encoding
+}
+
 func (h *Handler) setDocString(uri protocol.DocumentURI, doc string) {
 	h.setDoc(uri, []byte(doc))
 }
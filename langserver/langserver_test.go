@@ -0,0 +1,47 @@
+package langserver
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ToQoz/gopwt/assert"
+)
+
+func TestHandleInitialize(t *testing.T) {
+	tests := []struct {
+		params   string
+		expected positionEncoding
+	}{
+		{`{"capabilities": {}}`, utf16Encoding},
+		{`{"capabilities": {"general": {"positionEncodings": ["utf-32", "utf-16"]}}}`, utf32Encoding},
+		{`{"capabilities": {"general": {"positionEncodings": ["utf-7", "utf-8"]}}}`, utf8Encoding},
+		{`{"capabilities": {"general": {"positionEncodings": ["utf-7"]}}}`, utf16Encoding},
+	}
+	for _, tt := range tests {
+		handler := NewHandler()
+		var params initializeParams
+		assert.Require(t, json.Unmarshal([]byte(tt.params), &params) == nil)
+		result, err := handler.handleInitialize(params)
+		assert.Require(t, err == nil)
+		assert.OK(t, result.Capabilities.PositionEncoding == tt.expected, tt.params)
+		assert.OK(t, handler.getEncoding() == tt.expected, tt.params)
+
+		b, err := json.Marshal(result)
+		assert.Require(t, err == nil)
+		var raw struct {
+			Capabilities struct {
+				PositionEncoding                string `json:"positionEncoding"`
+				DefinitionProvider              bool   `json:"definitionProvider"`
+				DocumentFormattingProvider      bool   `json:"documentFormattingProvider"`
+				DocumentRangeFormattingProvider bool   `json:"documentRangeFormattingProvider"`
+				TextDocumentSync                int    `json:"textDocumentSync"`
+			} `json:"capabilities"`
+		}
+		assert.Require(t, json.Unmarshal(b, &raw) == nil)
+		assert.OK(t, raw.Capabilities.PositionEncoding == string(tt.expected))
+		assert.OK(t, raw.Capabilities.DefinitionProvider)
+		assert.OK(t, raw.Capabilities.DocumentFormattingProvider)
+		assert.OK(t, raw.Capabilities.DocumentRangeFormattingProvider)
+		assert.OK(t, raw.Capabilities.TextDocumentSync == 2)
+	}
+}
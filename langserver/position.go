@@ -0,0 +1,87 @@
+package langserver
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	protocol "github.com/sourcegraph/go-langserver/pkg/lsp"
+)
+
+// positionEncoding is the unit in which the character offsets of LSP
+// positions are counted. It is negotiated in initialize and defaults to
+// UTF-16 code units.
+type positionEncoding string
+
+const (
+	utf8Encoding  positionEncoding = "utf-8"
+	utf16Encoding positionEncoding = "utf-16"
+	utf32Encoding positionEncoding = "utf-32"
+)
+
+// supported reports whether the server can count characters in enc.
+func (enc positionEncoding) supported() bool {
+	switch enc {
+	case utf8Encoding, utf16Encoding, utf32Encoding:
+		return true
+	}
+	return false
+}
+
+// width returns the number of units of ch in enc.
+func (enc positionEncoding) width(ch rune) int {
+	switch enc {
+	case utf8Encoding:
+		return utf8.RuneLen(ch)
+	case utf32Encoding:
+		return 1
+	default:
+		if ch >= 0x10000 {
+			return 2 // surrogate pair
+		}
+		return 1
+	}
+}
+
+// offset converts the LSP position pos in src into a rune offset. As the
+// specification requires, a character beyond the end of a line means the
+// end of that line.
+func (enc positionEncoding) offset(src []rune, pos protocol.Position) (int, error) {
+	if pos.Line < 0 || pos.Character < 0 {
+		return 0, fmt.Errorf("invalid position %d:%d", pos.Line, pos.Character)
+	}
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		for offset < len(src) && src[offset] != '\n' {
+			offset++
+		}
+		if offset == len(src) {
+			return 0, fmt.Errorf("invalid position %d:%d: the document has only %d lines", pos.Line, pos.Character, line+1)
+		}
+		offset++
+	}
+	return enc.advance(src, offset, pos.Character), nil
+}
+
+// advance returns the rune offset n units after offset in src, stopping at
+// the end of the line.
+func (enc positionEncoding) advance(src []rune, offset, n int) int {
+	for n > 0 && offset < len(src) && src[offset] != '\n' {
+		n -= enc.width(src[offset])
+		offset++
+	}
+	return offset
+}
+
+// position converts the rune offset in src into an LSP position.
+func (enc positionEncoding) position(src []rune, offset int) protocol.Position {
+	var pos protocol.Position
+	for _, ch := range src[:offset] {
+		if ch == '\n' {
+			pos.Line++
+			pos.Character = 0
+		} else {
+			pos.Character += enc.width(ch)
+		}
+	}
+	return pos
+}
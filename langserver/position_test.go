@@ -0,0 +1,51 @@
+package langserver
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ToQoz/gopwt/assert"
+	protocol "github.com/sourcegraph/go-langserver/pkg/lsp"
+)
+
+func TestPositionEncoding(t *testing.T) {
+	src := []rune("a😃b\né\n\nx")
+	tests := []struct {
+		offset int
+		utf8   protocol.Position
+		utf16  protocol.Position
+		utf32  protocol.Position
+	}{
+		{0, protocol.Position{Line: 0, Character: 0}, protocol.Position{Line: 0, Character: 0}, protocol.Position{Line: 0, Character: 0}},
+		{1, protocol.Position{Line: 0, Character: 1}, protocol.Position{Line: 0, Character: 1}, protocol.Position{Line: 0, Character: 1}},
+		{2, protocol.Position{Line: 0, Character: 5}, protocol.Position{Line: 0, Character: 3}, protocol.Position{Line: 0, Character: 2}},
+		{3, protocol.Position{Line: 0, Character: 6}, protocol.Position{Line: 0, Character: 4}, protocol.Position{Line: 0, Character: 3}},
+		{4, protocol.Position{Line: 1, Character: 0}, protocol.Position{Line: 1, Character: 0}, protocol.Position{Line: 1, Character: 0}},
+		{5, protocol.Position{Line: 1, Character: 2}, protocol.Position{Line: 1, Character: 1}, protocol.Position{Line: 1, Character: 1}},
+		{6, protocol.Position{Line: 2, Character: 0}, protocol.Position{Line: 2, Character: 0}, protocol.Position{Line: 2, Character: 0}},
+		{8, protocol.Position{Line: 3, Character: 1}, protocol.Position{Line: 3, Character: 1}, protocol.Position{Line: 3, Character: 1}},
+	}
+	for _, tt := range tests {
+		for enc, pos := range map[positionEncoding]protocol.Position{utf8Encoding: tt.utf8, utf16Encoding: tt.utf16, utf32Encoding: tt.utf32} {
+			assert.OK(t, reflect.DeepEqual(enc.position(src, tt.offset), pos), string(enc))
+			offset, err := enc.offset(src, pos)
+			assert.Require(t, err == nil)
+			assert.OK(t, offset == tt.offset, string(enc))
+		}
+	}
+}
+
+func TestPositionEncoding_Clamp(t *testing.T) {
+	src := []rune("ab\ncd")
+	offset, err := utf16Encoding.offset(src, protocol.Position{Line: 0, Character: 10})
+	assert.Require(t, err == nil)
+	assert.OK(t, offset == 2)
+	offset, err = utf16Encoding.offset(src, protocol.Position{Line: 1, Character: 10})
+	assert.Require(t, err == nil)
+	assert.OK(t, offset == 5)
+
+	_, err = utf16Encoding.offset(src, protocol.Position{Line: 2, Character: 0})
+	assert.OK(t, err != nil)
+	_, err = utf16Encoding.offset(src, protocol.Position{Line: -1, Character: 0})
+	assert.OK(t, err != nil)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 	"net"
+	"os"
 
 	"github.com/blockchain-labs-org/solzaemon/langserver"
 	"github.com/sourcegraph/jsonrpc2"
@@ -12,6 +13,10 @@ import (
 var connOpt = []jsonrpc2.ConnOpt{}
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "fmt" {
+		os.Exit(runFmt(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+	}
+
 	log.Printf("listen :8080")
 	err := launch(langserver.NewHandler(), ":8080")
 	if err != nil {
@@ -0,0 +1,39 @@
+//go:build go1.18
+// +build go1.18
+
+package parser
+
+import (
+	"testing"
+
+	"github.com/blockchain-labs-org/solzaemon/ast"
+)
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		``,
+		`pragma solidity ^0.4.23;`,
+		`import "a.sol";`,
+		`contract A is B(1), C { uint256 public x = 1 ether; function f() public { x = g(x, y[1]).z; } }`,
+		`contract A { function f( { }`,
+		`contract A { uint x = (1 + ; } }`,
+		`) ] } ;`,
+		`contract A { mapping( => uint) m; function g() public { assembly } }`,
+		`function f() { assembly { let x := add(1, } }`,
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		got, err := parse(src)
+		if got == nil {
+			t.Fatalf("Parse(%q) returned no program: %v", src, err)
+		}
+		// the recovered tree has no nil children and valid ranges
+		ast.Inspect(got, func(n ast.Node) bool {
+			if n != nil && n.End() < n.Pos() {
+				t.Errorf("Parse(%q): %T ends at %d before its start %d", src, n, n.End(), n.Pos())
+			}
+			return true
+		})
+	})
+}
@@ -0,0 +1,122 @@
+package parser
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/blockchain-labs-org/solzaemon/ast"
+)
+
+// maxExponent bounds the exponent of rational literals that get a value,
+// so that 1e1000000000 does not exhaust memory.
+const maxExponent = 4096
+
+var unitMultipliers = map[string]int64{
+	"wei":     1,
+	"gwei":    1e9,
+	"ether":   1e18,
+	"seconds": 1,
+	"minutes": 60,
+	"hours":   60 * 60,
+	"days":    24 * 60 * 60,
+	"weeks":   7 * 24 * 60 * 60,
+}
+
+// numberValue returns the exact value of the number literal lit, multiplied
+// by its unit. It returns nil if the literal is malformed.
+func numberValue(lit *ast.BasicLit) *big.Rat {
+	digits := strings.Replace(lit.Value, "_", "", -1)
+
+	var v *big.Rat
+	if strings.HasPrefix(digits, "0x") {
+		i, ok := new(big.Int).SetString(digits[2:], 16)
+		if !ok {
+			return nil
+		}
+		v = new(big.Rat).SetInt(i)
+	} else {
+		if i := strings.IndexAny(digits, "eE"); i >= 0 {
+			exp, err := strconv.Atoi(digits[i+1:])
+			if err != nil || exp > maxExponent || exp < -maxExponent {
+				return nil
+			}
+		}
+		var ok bool
+		v, ok = new(big.Rat).SetString(digits)
+		if !ok {
+			return nil
+		}
+	}
+
+	if lit.Unit != nil {
+		v.Mul(v, big.NewRat(unitMultipliers[lit.Unit.Name], 1))
+	}
+	return v
+}
+
+// stringValue returns the decoded contents of the string literal lit.
+// Malformed escape sequences are kept as they are; the scanner has
+// already reported them.
+func stringValue(lit string) string {
+	if strings.HasPrefix(lit, "hex") {
+		if len(lit) < len(`hex""`) {
+			return ""
+		}
+		b, _ := hex.DecodeString(strings.Replace(lit[4:len(lit)-1], "_", "", -1))
+		return string(b)
+	}
+	lit = strings.TrimPrefix(lit, "unicode")
+	if len(lit) < 2 {
+		return ""
+	}
+	quoted := lit[1:]
+	if quoted[len(quoted)-1] == lit[0] {
+		quoted = quoted[:len(quoted)-1]
+	}
+
+	var b []byte
+	for i := 0; i < len(quoted); i++ {
+		if quoted[i] != '\\' || i+1 == len(quoted) {
+			b = append(b, quoted[i])
+			continue
+		}
+		i++
+		switch c := quoted[i]; c {
+		case 'n':
+			b = append(b, '\n')
+		case 'r':
+			b = append(b, '\r')
+		case 't':
+			b = append(b, '\t')
+		case '\n':
+			// line continuation
+		case 'x', 'u':
+			n := 2
+			if c == 'u' {
+				n = 4
+			}
+			if i+1+n > len(quoted) {
+				b = append(b, '\\', c)
+				continue
+			}
+			v, err := strconv.ParseUint(quoted[i+1:i+1+n], 16, 32)
+			if err != nil {
+				b = append(b, '\\', c)
+				continue
+			}
+			if c == 'x' {
+				b = append(b, byte(v))
+			} else {
+				var buf [utf8.UTFMax]byte
+				b = append(b, buf[:utf8.EncodeRune(buf[:], rune(v))]...)
+			}
+			i += n
+		default:
+			b = append(b, c)
+		}
+	}
+	return string(b)
+}
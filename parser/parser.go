@@ -2,275 +2,1414 @@ package parser
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/blockchain-labs-org/solzaemon/ast"
 	"github.com/blockchain-labs-org/solzaemon/scanner"
 	"github.com/blockchain-labs-org/solzaemon/token"
-	protocol "github.com/sourcegraph/go-langserver/pkg/lsp"
+	"github.com/blockchain-labs-org/solzaemon/yul"
 )
 
+// Parse parses the source code of a single Solidity file.
+// It always returns a Program, which may be incomplete and contain Bad
+// nodes if there were syntax errors. The errors are returned as a sorted
+// scanner.ErrorList.
 func Parse(f *token.File, src []rune) (*ast.Program, error) {
-	p := &Parser{}
-	p.scanner = scanner.NewScanner(f, src)
-	p.node = &ast.Program{}
-	return p.parse()
+	p := &Parser{file: f, src: src}
+	p.scanner = scanner.NewScanner(f, src, p.errors.Add, 0)
+	p.next()
+	program := p.parse()
+	p.errors.Sort()
+	return program, p.errors.Err()
 }
 
 type Parser struct {
-	// DefPos to ReferencePos list
+	file    *token.File
+	src     []rune
+	errors  scanner.ErrorList
 	scanner *scanner.Scanner
-	node    ast.Node
 	offset  token.Pos
 	tok     token.Token
 	lit     string
 }
 
-func (p *Parser) parse() (*ast.Program, error) {
-	program := &ast.Program{}
-	for {
+// ----------------------------------------------------------------------------
+// Parsing support
+
+func (p *Parser) next() {
+	p.offset, p.tok, p.lit = p.scanner.Scan()
+}
+
+func (p *Parser) error(pos token.Pos, msg string) {
+	p.errorAt(p.file.Position(pos), msg)
+}
+
+// errorAt is error for a Position. Its signature matches
+// scanner.ErrorHandler.
+func (p *Parser) errorAt(epos token.Position, msg string) {
+	// Report only the first error on a line; the rest are usually
+	// follow-up errors of the first one.
+	if n := len(p.errors); n > 0 && p.errors[n-1].Pos.Line == epos.Line {
+		return
+	}
+	p.errors.Add(epos, msg)
+}
+
+func (p *Parser) errorExpected(pos token.Pos, msg string) {
+	msg = "expected " + msg
+	if pos == p.offset {
+		// the error happened at the current position;
+		// make the error message more specific
+		switch {
+		case p.tok == token.EOF:
+			msg += ", found EOF"
+		case p.lit != "":
+			msg += ", found '" + p.lit + "'"
+		default:
+			msg += ", found " + p.tok.String()
+		}
+	}
+	p.error(pos, msg)
+}
+
+// expect reports an error unless the current token is tok, and consumes
+// the current token if it matches. It returns the position of the token.
+func (p *Parser) expect(tok token.Token) token.Pos {
+	pos := p.offset
+	if p.tok != tok {
+		p.errorExpected(pos, fmt.Sprintf("'%s'", tokenString(tok)))
+		return pos
+	}
+	p.next()
+	return pos
+}
+
+// tokenString returns the source text of punctuation tokens and keywords,
+// and the token name otherwise.
+func tokenString(tok token.Token) string {
+	switch tok {
+	case token.LPAREN:
+		return "("
+	case token.RPAREN:
+		return ")"
+	case token.LBRACE:
+		return "{"
+	case token.RBRACE:
+		return "}"
+	case token.LBRACK:
+		return "["
+	case token.RBRACK:
+		return "]"
+	case token.SEMICOLON:
+		return ";"
+	case token.COMMA:
+		return ","
+	case token.ASSIGN:
+		return "="
+	case token.COLON:
+		return ":"
+	case token.DARROW:
+		return "=>"
+	}
+	if tok.IsKeyword() {
+		return strings.ToLower(tok.String())
+	}
+	return tok.String()
+}
+
+// topLevelStart is the set of tokens a top-level declaration starts with.
+// The parser resynchronizes at them after an error.
+var topLevelStart = map[token.Token]bool{
+	token.PRAGMA:    true,
+	token.IMPORT:    true,
+	token.ABSTRACT:  true,
+	token.CONTRACT:  true,
+	token.INTERFACE: true,
+	token.LIBRARY:   true,
+}
+
+// fileStart is the set of tokens the parser resynchronizes at after an
+// error at the top level.
+var fileStart = map[token.Token]bool{
+	token.TYPE: true,
+}
+
+func init() {
+	for tok := range topLevelStart {
+		fileStart[tok] = true
+	}
+	for tok := range declStart {
+		fileStart[tok] = true
+	}
+}
+
+// advance consumes tokens until the current token is in the to set or EOF.
+func (p *Parser) advance(to map[token.Token]bool) {
+	for p.tok != token.EOF && !to[p.tok] {
 		p.next()
-		if p.tok == 0 {
-			break
+	}
+}
+
+// declStart is the set of tokens a contract member starts with.
+var declStart = map[token.Token]bool{
+	token.CONSTRUCTOR: true,
+	token.FUNCTION:    true,
+	token.MODIFIER:    true,
+	token.EVENT:       true,
+	token.STRUCT:      true,
+	token.ENUM:        true,
+	token.USING:       true,
+}
+
+// skip consumes the remaining tokens of a declaration or statement: it
+// stops after a ';' or a balanced '{...}' block, or before a '}' closing
+// the enclosing block. It also stops before top-level keywords and, outside
+// of blocks, before a token in the to set.
+func (p *Parser) skip(to map[token.Token]bool) {
+	depth := 0
+	for p.tok != token.EOF && !topLevelStart[p.tok] {
+		if depth == 0 && to[p.tok] {
+			return
 		}
-		if p.tok == token.IDENT {
-			switch p.lit {
-			case "pragma":
-				directive, err := p.parsePragma()
-				if err != nil {
-					return nil, err
-				}
-				program.PragmaDirective = directive
-			case "import":
-				imp, err := p.parseImport()
-				if err != nil {
-					return nil, err
-				}
-				program.ImportDirectives = append(program.ImportDirectives, imp)
-			case "contract":
-				contract, err := p.parseContract()
-				if err != nil {
-					return nil, err
-				}
-				program.ContractDefinition = append(program.ContractDefinition, contract)
+		switch p.tok {
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			if depth == 0 {
+				return
+			}
+			depth--
+			if depth == 0 {
+				p.next()
+				return
+			}
+		case token.SEMICOLON:
+			if depth == 0 {
+				p.next()
+				return
 			}
 		}
+		p.next()
 	}
-	return program, nil
 }
 
-func (p *Parser) parsePragma() (*ast.PragmaDirective, error) {
-	p.next()
-	name := p.lit
-	p.next()
-	val := ""
-	for {
-		if p.tok == token.SEMICOLON {
-			break
+// ----------------------------------------------------------------------------
+// Declarations
+
+func (p *Parser) parse() *ast.Program {
+	program := &ast.Program{}
+	for p.tok != token.EOF {
+		var unit ast.Decl
+		switch p.tok {
+		case token.PRAGMA:
+			pragma := p.parsePragma()
+			program.PragmaDirectives = append(program.PragmaDirectives, pragma)
+			unit = pragma
+		case token.IMPORT:
+			imp := p.parseImport()
+			program.ImportDirectives = append(program.ImportDirectives, imp)
+			unit = imp
+		case token.ABSTRACT, token.CONTRACT, token.INTERFACE, token.LIBRARY:
+			contract := p.parseContract()
+			program.ContractDefinition = append(program.ContractDefinition, contract)
+			unit = contract
+		default:
+			// file-level functions, constants and types
+			unit = p.parseDecl()
+			if unit == nil {
+				p.errorExpected(p.offset, "pragma, import, contract or declaration")
+				p.next()
+				p.advance(fileStart)
+			}
+		}
+		if unit != nil {
+			program.Units = append(program.Units, unit)
 		}
-		val += p.lit
+	}
+	return program
+}
+
+func (p *Parser) parsePragma() *ast.PragmaDirective {
+	pragma := &ast.PragmaDirective{}
+	pragma.Pragma = p.expect(token.PRAGMA)
+	pragma.Name = p.parseIdent()
+	pragma.ValuePos = p.offset
+	end := p.offset
+	for p.tok != token.SEMICOLON && p.tok != token.EOF && !topLevelStart[p.tok] {
+		end = p.offset + token.Pos(utf8.RuneCountInString(p.lit))
 		p.next()
 	}
-	return &ast.PragmaDirective{
-		Name:  &ast.Ident{Name: name, NamePos: p.offset},
-		Value: val,
-	}, nil
+	pragma.Value = string(p.src[p.file.Offset(pragma.ValuePos):p.file.Offset(end)])
+	pragma.Semicolon = p.expect(token.SEMICOLON)
+	if pragma.Name.Name == "solidity" {
+		pragma.Version = p.parseVersionRange(pragma.ValuePos, pragma.Value)
+	}
+	return pragma
 }
 
-func (p *Parser) parseImport() (*ast.ImportDirective, error) {
+func (p *Parser) parseImport() *ast.ImportDirective {
+	imp := &ast.ImportDirective{}
+	imp.Import = p.expect(token.IMPORT)
+	switch p.tok {
+	case token.MUL:
+		imp.Star = p.offset
+		p.next()
+		p.expect(token.AS)
+		imp.Alias = p.parseIdent()
+		p.expectFrom()
+		p.parseImportPath(imp)
+	case token.LBRACE:
+		p.next()
+		for p.tok != token.RBRACE && p.tok != token.EOF {
+			sym := &ast.ImportSymbol{Name: p.parseIdent()}
+			if p.tok == token.AS {
+				p.next()
+				sym.Alias = p.parseIdent()
+			}
+			imp.Symbols = append(imp.Symbols, sym)
+			if p.tok != token.COMMA {
+				break
+			}
+			p.next()
+		}
+		p.expect(token.RBRACE)
+		p.expectFrom()
+		p.parseImportPath(imp)
+	default:
+		p.parseImportPath(imp)
+		if p.tok == token.AS {
+			p.next()
+			imp.Alias = p.parseIdent()
+		}
+	}
+	imp.Semicolon = p.expectSemi()
+	return imp
+}
+
+// expectFrom expects the contextual keyword from.
+func (p *Parser) expectFrom() {
+	if p.tok != token.IDENT || p.lit != "from" {
+		p.errorExpected(p.offset, "'from'")
+		return
+	}
 	p.next()
-	return &ast.ImportDirective{Path: protocol.DocumentURI(p.lit)}, nil
 }
 
-func (p *Parser) parseContract() (*ast.ContractPart, error) {
-	part := &ast.ContractPart{}
+func (p *Parser) parseImportPath(imp *ast.ImportDirective) {
+	imp.PathPos = p.offset
+	if p.tok != token.STRING {
+		p.errorExpected(p.offset, "import path")
+		return
+	}
+	imp.Path = stringValue(p.lit)
 	p.next()
-	p.expect(token.IDENT)
+}
+
+func (p *Parser) parseContract() *ast.ContractPart {
+	part := &ast.ContractPart{Contract: p.offset}
+	if p.tok == token.ABSTRACT {
+		part.Abstract = true
+		p.next()
+		if p.tok != token.CONTRACT {
+			p.errorExpected(p.offset, "'contract'")
+		}
+	}
+	part.Kind = token.CONTRACT
+	switch p.tok {
+	case token.CONTRACT, token.INTERFACE, token.LIBRARY:
+		part.Kind = p.tok
+		p.next()
+	}
 	part.Name = p.parseIdent()
 
-	if p.tok == token.IDENT && p.lit == "is" {
+	if p.tok == token.IS {
 		p.next()
 		for {
-			part.Inherits = append(part.Inherits, &ast.Ident{Name: p.lit, NamePos: p.offset})
-			p.next()
+			base := p.parseModifierInvocation()
+			if name, ok := base.Fun.(*ast.Ident); ok {
+				part.Inherits = append(part.Inherits, name)
+			}
+			part.Bases = append(part.Bases, base)
 			if p.tok != token.COMMA {
 				break
 			}
+			p.next()
 		}
 	}
-	p.expect(token.LBRACE)
+	part.Lbrace = p.expect(token.LBRACE)
 
-	for {
-		p.next()
-		if p.tok == 0 {
-			return part, nil
+	for p.tok != token.RBRACE && p.tok != token.EOF && !topLevelStart[p.tok] {
+		decl := p.parseDecl()
+		switch decl := decl.(type) {
+		case nil:
+			p.errorExpected(p.offset, "contract member declaration")
+			p.skip(declStart)
+			continue
+		case *ast.FunctionDefinition:
+			part.FunctionDefinitions = append(part.FunctionDefinitions, decl)
+		case *ast.StateVariableDeclaration:
+			part.StateVariableDeclarations = append(part.StateVariableDeclarations, decl)
 		}
+		part.Members = append(part.Members, decl)
+	}
+	part.Rbrace = p.expect(token.RBRACE)
+	return part
+}
 
-		switch p.tok {
-		case token.RBRACE:
-			return part, nil
-		case token.IDENT:
-			switch p.lit {
-			case "constructor", "function":
-				fn, err := p.parseFunction()
-				if err != nil {
-					return nil, err
-				}
-				part.FunctionDefinitions = append(part.FunctionDefinitions, fn)
-			default:
-				stateVar, err := p.parseStateVariable()
-				if err != nil {
-					return nil, err
-				}
-				part.StateVariableDeclarations = append(part.StateVariableDeclarations, stateVar)
-			}
+// parseDecl parses a contract member or a file-level declaration. It
+// returns nil without consuming anything if no declaration starts at the
+// current token.
+func (p *Parser) parseDecl() ast.Decl {
+	switch p.tok {
+	case token.CONSTRUCTOR, token.FUNCTION:
+		return p.parseFunction()
+	case token.MODIFIER:
+		return p.parseModifier()
+	case token.EVENT:
+		return p.parseEvent()
+	case token.STRUCT:
+		return p.parseStruct()
+	case token.ENUM:
+		return p.parseEnum()
+	case token.USING:
+		return p.parseUsing()
+	case token.TYPE:
+		return p.parseUserDefinedValueType()
+	case token.IDENT:
+		// error, fallback and receive are not keywords
+		name := p.parseIdent()
+		switch {
+		case name.Name == "error" && p.tok == token.IDENT:
+			return p.parseError(name.NamePos)
+		case (name.Name == "fallback" || name.Name == "receive") && p.tok == token.LPAREN:
+			return p.parseFunctionRest(name.NamePos, name)
 		}
+		return p.parseStateVariable(p.parseTypeFrom(name))
+	case token.MAPPING, token.BYTE:
+		return p.parseStateVariable(p.parseType())
 	}
-	return part, nil
+	return nil
 }
 
-func (p *Parser) parseStateVariable() (*ast.StateVariableDeclaration, error) {
+// parseStateVariable parses a state variable declaration after its type.
+func (p *Parser) parseStateVariable(typ ast.TypeName) *ast.StateVariableDeclaration {
 	stateVar := &ast.StateVariableDeclaration{}
-	stateVar.Typ = &ast.Ident{Name: p.lit, NamePos: p.offset}
+	stateVar.Typ = typ
 
 done:
 	for {
-		p.next()
 		switch p.tok {
-		case token.IDENT:
-			switch p.lit {
-			case "constant":
-				stateVar.IsConstant = true
-			case "public", "internal", "private":
-				stateVar.Visibility = p.lit
-			default:
-				stateVar.Name = &ast.Ident{Name: p.lit, NamePos: p.offset}
-				break done
-			}
+		case token.CONSTANT:
+			stateVar.IsConstant = true
+		case token.IMMUTABLE:
+			stateVar.Immutable = true
+		case token.PUBLIC, token.INTERNAL, token.PRIVATE:
+			stateVar.Visibility = p.lit
+		default:
+			break done
 		}
+		p.next()
+	}
+	stateVar.Name = p.parseIdent()
+
+	if p.tok == token.ASSIGN {
+		p.next()
+		stateVar.Rhs = p.parseExpr()
 	}
+	stateVar.Semicolon = p.expectSemi()
+
+	return stateVar
+}
 
-	p.expectNext(token.ASSIGN)
+// expectSemi expects the ';' terminating a declaration and skips the rest
+// of the declaration if it is missing. Like expect, it returns the
+// position of the current token.
+func (p *Parser) expectSemi() token.Pos {
+	pos := p.offset
+	if p.tok != token.SEMICOLON {
+		p.errorExpected(pos, "';'")
+		p.skip(declStart)
+		return pos
+	}
 	p.next()
+	return pos
+}
 
-	rhs := p.parseBinaryExpr()
-	stateVar.Rhs = rhs
+func (p *Parser) parseModifier() *ast.ModifierDefinition {
+	modifier := &ast.ModifierDefinition{}
+	modifier.Modifier = p.expect(token.MODIFIER)
+	modifier.Name = p.parseIdent()
+	if p.tok == token.LPAREN {
+		modifier.Params = p.parseParameterList()
+	}
+	for p.tok == token.VIRTUAL || p.tok == token.OVERRIDE {
+		if p.tok == token.VIRTUAL {
+			modifier.Virtual = true
+			p.next()
+		} else {
+			modifier.Override = p.parseOverride()
+		}
+	}
+	if p.tok == token.SEMICOLON {
+		modifier.Semicolon = p.offset
+		p.next()
+		return modifier
+	}
+	modifier.Body = p.parseBlockStmt()
+	return modifier
+}
 
-	return stateVar, nil
+func (p *Parser) parseEvent() *ast.EventDefinition {
+	event := &ast.EventDefinition{}
+	event.Event = p.expect(token.EVENT)
+	event.Name = p.parseIdent()
+	event.Params = p.parseParameterList()
+	if p.tok == token.ANONYMOUS {
+		event.Anonymous = true
+		p.next()
+	}
+	event.Semicolon = p.expectSemi()
+	return event
 }
 
-func (p *Parser) next() {
-	p.offset, p.tok, p.lit = p.scanner.Scan()
+// parseUserDefinedValueType parses `type Price is uint128;`.
+func (p *Parser) parseUserDefinedValueType() *ast.UserDefinedValueTypeDefinition {
+	def := &ast.UserDefinedValueTypeDefinition{}
+	def.Type = p.expect(token.TYPE)
+	def.Name = p.parseIdent()
+	p.expect(token.IS)
+	def.Underlying = p.parseType()
+	def.Semicolon = p.expectSemi()
+	return def
+}
+
+// parseError parses an error definition after the error identifier at pos.
+func (p *Parser) parseError(pos token.Pos) *ast.ErrorDefinition {
+	def := &ast.ErrorDefinition{Error: pos}
+	def.Name = p.parseIdent()
+	def.Params = p.parseParameterList()
+	def.Semicolon = p.expectSemi()
+	return def
+}
+
+func (p *Parser) parseStruct() *ast.StructDefinition {
+	def := &ast.StructDefinition{}
+	def.Struct = p.expect(token.STRUCT)
+	def.Name = p.parseIdent()
+	def.Lbrace = p.expect(token.LBRACE)
+	for p.tok != token.RBRACE && p.tok != token.EOF && !topLevelStart[p.tok] && !declStart[p.tok] {
+		field := &ast.Parameter{}
+		field.Typ = p.parseType()
+		field.Name = p.parseIdent()
+		def.Fields = append(def.Fields, field)
+		p.expectSemi()
+	}
+	def.Rbrace = p.expect(token.RBRACE)
+	return def
+}
+
+func (p *Parser) parseEnum() *ast.EnumDefinition {
+	def := &ast.EnumDefinition{}
+	def.Enum = p.expect(token.ENUM)
+	def.Name = p.parseIdent()
+	def.Lbrace = p.expect(token.LBRACE)
+	for p.tok == token.IDENT {
+		def.Values = append(def.Values, p.parseIdent())
+		if p.tok != token.COMMA {
+			break
+		}
+		p.next()
+	}
+	def.Rbrace = p.expect(token.RBRACE)
+	return def
+}
+
+func (p *Parser) parseUsing() *ast.UsingDirective {
+	using := &ast.UsingDirective{}
+	using.Using = p.expect(token.USING)
+	if p.tok == token.LBRACE {
+		p.next()
+		for p.tok != token.RBRACE && p.tok != token.EOF {
+			using.Functions = append(using.Functions, p.parseIdentPath())
+			if p.tok == token.AS {
+				// NOTE: user-defined operators are not supported yet
+				p.next()
+				if p.tok != token.COMMA && p.tok != token.RBRACE {
+					p.next()
+				}
+			}
+			if p.tok != token.COMMA {
+				break
+			}
+			p.next()
+		}
+		p.expect(token.RBRACE)
+	} else {
+		using.Library = p.parseIdentPath()
+	}
+	p.expect(token.FOR)
+	if p.tok == token.MUL {
+		p.next()
+	} else {
+		using.Typ = p.parseType()
+	}
+	if p.tok == token.IDENT && p.lit == "global" {
+		using.Global = true
+		p.next()
+	}
+	using.Semicolon = p.expectSemi()
+	return using
 }
 
-func (p *Parser) parseFunction() (*ast.FunctionDefinition, error) {
-	functionDef := &ast.FunctionDefinition{}
-	if p.lit != "constructor" {
+// parseFunction parses a function or constructor definition. A state
+// variable of a function type is parsed as well, as it starts alike.
+func (p *Parser) parseFunction() ast.Decl {
+	if p.tok == token.CONSTRUCTOR {
+		name := p.parseKeyword()
+		return p.parseFunctionRest(name.NamePos, name)
+	}
+	pos := p.expect(token.FUNCTION)
+	if p.tok != token.LPAREN {
+		return p.parseFunctionRest(pos, p.parseIdent())
+	}
+	// either the type of a state variable or an unnamed fallback function
+	// of Solidity < 0.6
+	typ := p.parseFunctionTypeFrom(pos)
+	switch p.tok {
+	case token.IDENT, token.INTERNAL, token.PRIVATE, token.CONSTANT, token.IMMUTABLE, token.LBRACK:
+		return p.parseStateVariable(p.parseArrayType(typ))
+	case token.PUBLIC:
+		// a fallback function may be public too
+		if p.stateVariableAhead() {
+			return p.parseStateVariable(typ)
+		}
+	}
+	return p.parseFunctionSpecifiers(&ast.FunctionDefinition{
+		Function:      pos,
+		Params:        typ.Params,
+		Visibility:    typ.Visibility,
+		VisibilityPos: typ.VisibilityPos,
+		Mutability:    typ.Mutability,
+		MutabilityPos: typ.MutabilityPos,
+		Returns:       typ.Returns,
+	})
+}
+
+// stateVariableAhead reports whether the current and next tokens continue
+// a state variable declaration after its type: specifiers, the name, and
+// ';' or '='.
+func (p *Parser) stateVariableAhead() bool {
+	toks := append([]token.Token{p.tok}, p.scanner.PeekN(5)...)
+	for i, tok := range toks {
+		switch tok {
+		case token.PUBLIC, token.INTERNAL, token.PRIVATE, token.CONSTANT, token.IMMUTABLE:
+			continue
+		case token.IDENT:
+			return i+1 < len(toks) && (toks[i+1] == token.SEMICOLON || toks[i+1] == token.ASSIGN)
+		}
+		return false
+	}
+	return false
+}
+
+// parseFunctionRest parses a function definition starting at pos after its
+// name.
+func (p *Parser) parseFunctionRest(pos token.Pos, name *ast.Ident) *ast.FunctionDefinition {
+	functionDef := &ast.FunctionDefinition{Function: pos}
+	functionDef.Name = name
+	functionDef.Params = p.parseParameterList()
+	return p.parseFunctionSpecifiers(functionDef)
+}
+
+// parseFunctionSpecifiers parses the specifiers and the body of a function
+// definition after its parameters.
+func (p *Parser) parseFunctionSpecifiers(functionDef *ast.FunctionDefinition) *ast.FunctionDefinition {
+	for p.tok != token.LBRACE && p.tok != token.SEMICOLON && p.tok != token.RBRACE && p.tok != token.EOF && !topLevelStart[p.tok] && !declStart[p.tok] {
+		switch p.tok {
+		case token.PUBLIC, token.INTERNAL, token.PRIVATE, token.EXTERNAL:
+			functionDef.Visibility = p.lit
+			functionDef.VisibilityPos = p.offset
+			p.next()
+		case token.PURE, token.VIEW, token.PAYABLE, token.CONSTANT:
+			functionDef.Mutability = p.lit
+			functionDef.MutabilityPos = p.offset
+			p.next()
+		case token.VIRTUAL:
+			functionDef.Virtual = true
+			p.next()
+		case token.OVERRIDE:
+			functionDef.Override = p.parseOverride()
+		case token.RETURNS:
+			p.next()
+			functionDef.Returns = p.parseParameterList()
+		case token.IDENT:
+			functionDef.Modifiers = append(functionDef.Modifiers, p.parseModifierInvocation())
+		default:
+			p.errorExpected(p.offset, "function specifier or body")
+			p.next()
+		}
+	}
+	if functionDef.Visibility == "" {
+		functionDef.Visibility = "public"
+	}
+
+	if p.tok == token.SEMICOLON {
+		functionDef.Semicolon = p.offset
 		p.next()
+		return functionDef
 	}
-	functionDef.Name = &ast.Ident{Name: p.lit, NamePos: p.offset}
-	p.expectNext(token.LPAREN)
-	// NOTE: args is not supported yet
-	p.expectNext(token.RPAREN)
+	functionDef.Body = p.parseBlockStmt()
+
+	return functionDef
+}
 
+func (p *Parser) parseOverride() *ast.OverrideSpecifier {
+	override := &ast.OverrideSpecifier{}
+	override.Override = p.expect(token.OVERRIDE)
+	if p.tok != token.LPAREN {
+		return override
+	}
+	override.Lparen = p.offset
 	p.next()
+	for p.tok != token.RPAREN && p.tok != token.EOF {
+		override.Overrides = append(override.Overrides, p.parseIdentPath())
+		if p.tok != token.COMMA {
+			break
+		}
+		p.next()
+	}
+	override.Rparen = p.expect(token.RPAREN)
+	return override
+}
+
+// parseModifierInvocation parses a modifier invocation like `onlyOwner` or
+// `onlyRole(ADMIN)`, a base constructor call of a constructor, or an
+// inheritance specifier like `ERC20("Token", "TKN")`.
+func (p *Parser) parseModifierInvocation() *ast.CallExpr {
+	name := p.parseIdentPath()
+	if p.tok == token.LPAREN {
+		return p.parseCallOrConversion(name)
+	}
+	return &ast.CallExpr{Fun: name}
+}
+
+// parseIdentPath parses a dotted path of identifiers like `A.B.c`.
+func (p *Parser) parseIdentPath() ast.Expr {
+	var x ast.Expr = p.parseIdent()
+	for p.tok == token.PERIOD {
+		p.next()
+		x = &ast.SelectorExpr{X: x, Sel: p.parseIdent()}
+	}
+	return x
+}
+
+func (p *Parser) parseParameterList() *ast.ParameterList {
+	params := &ast.ParameterList{}
+	params.Lparen = p.expect(token.LPAREN)
+	for p.tok != token.RPAREN && p.tok != token.EOF {
+		params.List = append(params.List, p.parseParameter())
+		if p.tok != token.COMMA {
+			break
+		}
+		p.next()
+	}
+	if p.tok != token.RPAREN {
+		p.errorExpected(p.offset, "')'")
+		// skip the rest of a malformed list, but never a body or the
+		// next declaration
+		for p.tok != token.RPAREN && p.tok != token.LBRACE && p.tok != token.RBRACE && p.tok != token.SEMICOLON &&
+			p.tok != token.EOF && !topLevelStart[p.tok] && !declStart[p.tok] {
+			p.next()
+		}
+	}
+	params.Rparen = p.offset
+	if p.tok == token.RPAREN {
+		p.next()
+	}
+	return params
+}
+
+func (p *Parser) parseParameter() *ast.Parameter {
+	param := &ast.Parameter{}
+	param.Typ = p.parseType()
+	if p.tok == token.INDEXED {
+		param.Indexed = p.offset
+		p.next()
+	}
+	switch p.tok {
+	case token.MEMORY, token.STORAGE, token.CALLDATA:
+		param.Location = p.parseKeyword()
+	}
 	if p.tok == token.IDENT {
-		if p.lit == "public" || p.lit == "internal" || p.lit == "private" {
-			functionDef.Visibility = p.lit
+		param.Name = p.parseIdent()
+	}
+	return param
+}
+
+// parseType parses a type name.
+func (p *Parser) parseType() ast.TypeName {
+	switch p.tok {
+	case token.MAPPING:
+		return p.parseArrayType(p.parseMapping())
+	case token.FUNCTION:
+		return p.parseArrayType(p.parseFunctionTypeFrom(p.expect(token.FUNCTION)))
+	case token.BYTE:
+		return p.parseTypeFrom(p.parseKeyword())
+	}
+	return p.parseTypeFrom(p.parseIdent())
+}
+
+// parseTypeFrom parses the rest of an elementary or user-defined type name
+// after its first identifier, including array dimensions.
+func (p *Parser) parseTypeFrom(name *ast.Ident) ast.TypeName {
+	if p.tok != token.PERIOD && isElementaryType(name.Name) {
+		typ := &ast.ElementaryType{NamePos: name.NamePos, Name: name.Name}
+		if typ.Name == "address" && p.tok == token.PAYABLE {
+			typ.Payable = p.offset
+			p.next()
+		}
+		return p.parseArrayType(typ)
+	}
+	typ := &ast.UserDefinedType{Path: []*ast.Ident{name}}
+	for p.tok == token.PERIOD {
+		p.next()
+		typ.Path = append(typ.Path, p.parseIdent())
+	}
+	return p.parseArrayType(typ)
+}
+
+// parseArrayType parses the array dimensions following elt, if any.
+func (p *Parser) parseArrayType(elt ast.TypeName) ast.TypeName {
+	for p.tok == token.LBRACK {
+		arr := &ast.ArrayType{Elt: elt}
+		arr.Lbrack = p.offset
+		p.next()
+		if p.tok != token.RBRACK {
+			arr.Len = p.parseExpr()
 		}
+		arr.Rbrack = p.expect(token.RBRACK)
+		elt = arr
+	}
+	return elt
+}
 
-		p.expectNext(token.LBRACE)
+func (p *Parser) parseMapping() *ast.Mapping {
+	mapping := &ast.Mapping{}
+	mapping.Mapping = p.expect(token.MAPPING)
+	mapping.Lparen = p.expect(token.LPAREN)
+	if p.tok == token.IDENT {
+		pos := p.offset
+		mapping.Key = p.parseTypeFrom(p.parseIdent())
+		if _, isArray := mapping.Key.(*ast.ArrayType); isArray {
+			p.error(pos, "expected elementary or user-defined key type")
+		}
 	} else {
-		p.expect(token.LBRACE)
-		functionDef.Visibility = "public"
+		pos := p.offset
+		p.errorExpected(pos, "key type")
+		end := pos
+		if p.tok != token.DARROW && p.tok != token.RPAREN && p.tok != token.EOF {
+			end += token.Pos(utf8.RuneCountInString(p.lit))
+			p.next()
+		}
+		mapping.Key = &ast.BadExpr{From: pos, To: end}
 	}
+	if p.tok == token.IDENT {
+		mapping.KeyName = p.parseIdent()
+	}
+	p.expect(token.DARROW)
+	mapping.Value = p.parseType()
+	if p.tok == token.IDENT {
+		mapping.ValueName = p.parseIdent()
+	}
+	mapping.Rparen = p.expect(token.RPAREN)
+	return mapping
+}
 
-	// stmts
+// parseFunctionTypeFrom parses a function type after the function keyword
+// at pos.
+func (p *Parser) parseFunctionTypeFrom(pos token.Pos) *ast.FunctionType {
+	typ := &ast.FunctionType{Function: pos}
+	typ.Params = p.parseParameterList()
 	for {
+		switch p.tok {
+		case token.INTERNAL, token.EXTERNAL:
+			if typ.Visibility != "" {
+				return typ
+			}
+			typ.Visibility = p.lit
+			typ.VisibilityPos = p.offset
+			p.next()
+		case token.PURE, token.VIEW, token.PAYABLE:
+			typ.Mutability = p.lit
+			typ.MutabilityPos = p.offset
+			p.next()
+		case token.RETURNS:
+			p.next()
+			typ.Returns = p.parseParameterList()
+			return typ
+		default:
+			return typ
+		}
+	}
+}
+
+// isElementaryType reports whether name is a built-in type name like
+// uint256, bytes32 or fixed128x18.
+func isElementaryType(name string) bool {
+	switch name {
+	case "address", "bool", "string", "bytes", "byte", "int", "uint", "fixed", "ufixed":
+		return true
+	}
+	switch {
+	case strings.HasPrefix(name, "uint"):
+		return isSize(name[len("uint"):], 8, 256, 8)
+	case strings.HasPrefix(name, "int"):
+		return isSize(name[len("int"):], 8, 256, 8)
+	case strings.HasPrefix(name, "bytes"):
+		return isSize(name[len("bytes"):], 1, 32, 1)
+	case strings.HasPrefix(name, "ufixed"):
+		return isFixedSize(name[len("ufixed"):])
+	case strings.HasPrefix(name, "fixed"):
+		return isFixedSize(name[len("fixed"):])
+	}
+	return false
+}
+
+// isSize reports whether s is a multiple of step between min and max
+// without leading zeros.
+func isSize(s string, min, max, step int) bool {
+	n, err := strconv.Atoi(s)
+	return err == nil && strconv.Itoa(n) == s && min <= n && n <= max && n%step == 0
+}
+
+// isFixedSize reports whether s is the MxN suffix of a fixed point type.
+func isFixedSize(s string) bool {
+	i := strings.IndexByte(s, 'x')
+	return i >= 0 && isSize(s[:i], 8, 256, 8) && isSize(s[i+1:], 0, 80, 1)
+}
+
+// ----------------------------------------------------------------------------
+// Statements
+
+func (p *Parser) parseBlockStmt() *ast.BlockStmt {
+	block := &ast.BlockStmt{}
+	block.Lbrace = p.expect(token.LBRACE)
+	for p.tok != token.RBRACE && p.tok != token.EOF && !topLevelStart[p.tok] && !p.atDeclStart() {
+		block.List = append(block.List, p.parseStmt())
+	}
+	block.Rbrace = p.expect(token.RBRACE)
+	return block
+}
+
+// atDeclStart reports whether a declaration starts at the current token,
+// which then cannot start a statement. A function type like
+// `function (uint) external` may start a local variable declaration.
+func (p *Parser) atDeclStart() bool {
+	return declStart[p.tok] && !(p.tok == token.FUNCTION && p.scanner.Peek() == token.LPAREN)
+}
+
+func (p *Parser) parseStmt() ast.Stmt {
+	switch p.tok {
+	case token.SEMICOLON:
+		stmt := &ast.EmptyStmt{Semicolon: p.offset}
+		p.next()
+		return stmt
+	case token.LBRACE:
+		return p.parseBlockStmt()
+	case token.UNCHECKED:
+		stmt := &ast.UncheckedStmt{Unchecked: p.offset}
 		p.next()
-		expr := p.parseBinaryExpr()
-		if expr == nil {
+		stmt.Body = p.parseBlockStmt()
+		return stmt
+	case token.IF:
+		return p.parseIfStmt()
+	case token.FOR:
+		return p.parseForStmt()
+	case token.WHILE:
+		stmt := &ast.WhileStmt{While: p.offset}
+		p.next()
+		stmt.Cond = p.parseCond()
+		stmt.Body = p.parseStmt()
+		return stmt
+	case token.DO:
+		stmt := &ast.DoWhileStmt{Do: p.offset}
+		p.next()
+		stmt.Body = p.parseStmt()
+		stmt.While = p.expect(token.WHILE)
+		stmt.Cond = p.parseCond()
+		stmt.Semicolon = p.expectStmtSemi()
+		return stmt
+	case token.BREAK, token.CONTINUE:
+		stmt := &ast.BranchStmt{TokPos: p.offset, Tok: p.tok}
+		p.next()
+		stmt.Semicolon = p.expectStmtSemi()
+		return stmt
+	case token.RETURN:
+		stmt := &ast.ReturnStmt{Return: p.offset}
+		p.next()
+		if p.tok != token.SEMICOLON {
+			stmt.Result = p.parseExpr()
+		}
+		stmt.Semicolon = p.expectStmtSemi()
+		return stmt
+	case token.EMIT:
+		stmt := &ast.EmitStmt{Emit: p.offset}
+		p.next()
+		stmt.Call = p.parseCallExpr("event call")
+		stmt.Semicolon = p.expectStmtSemi()
+		return stmt
+	case token.TRY:
+		return p.parseTryStmt()
+	case token.ASSEMBLY:
+		return p.parseAssemblyStmt()
+	case token.IDENT:
+		if p.lit == "revert" {
+			// revert is not a keyword: `revert E(x);` is a statement, but
+			// `revert("reason");` is a call
+			name := p.parseIdent()
+			if p.tok == token.IDENT {
+				stmt := &ast.RevertStmt{Revert: name.NamePos}
+				stmt.Call = p.parseCallExpr("error call")
+				stmt.Semicolon = p.expectStmtSemi()
+				return stmt
+			}
+			return p.parseExprStmt(name.NamePos, p.parseExprFrom(p.parsePrimaryExpr(name)))
+		}
+	}
+
+	return p.parseSimpleStmt()
+}
+
+// parseSimpleStmt parses a variable declaration or an expression statement.
+// A declaration is told apart from an expression by the token following
+// its type, which is parsed as an expression first.
+func (p *Parser) parseSimpleStmt() ast.Stmt {
+	from := p.offset
+	switch p.tok {
+	case token.MAPPING, token.FUNCTION:
+		return p.parseVarDeclStmt(p.parseType())
+	case token.LPAREN:
+		return p.parseTupleStmt()
+	case token.IDENT:
+		x := p.parsePrimaryExpr(nil)
+		if isVarDeclStart(p.tok) {
+			return p.parseVarDeclStmt(p.toType(x))
+		}
+		return p.parseExprStmt(from, p.parseExprFrom(x))
+	}
+	return p.parseExprStmt(from, p.parseExpr())
+}
+
+// isVarDeclStart reports whether tok may follow the type of a variable
+// declaration.
+func isVarDeclStart(tok token.Token) bool {
+	switch tok {
+	case token.IDENT, token.MEMORY, token.STORAGE, token.CALLDATA, token.PAYABLE:
+		return true
+	}
+	return false
+}
+
+// toType converts x, parsed as an expression, into a type name. It reports
+// an error if x is not a type name.
+func (p *Parser) toType(x ast.Expr) ast.TypeName {
+	switch t := x.(type) {
+	case *ast.ElementaryType, *ast.BadExpr:
+		return x
+	case *ast.Ident:
+		return &ast.UserDefinedType{Path: []*ast.Ident{t}}
+	case *ast.SelectorExpr:
+		if typ, ok := p.toType(t.X).(*ast.UserDefinedType); ok {
+			if sel, ok := t.Sel.(*ast.Ident); ok {
+				typ.Path = append(typ.Path, sel)
+				return typ
+			}
+		}
+	case *ast.IndexExpr:
+		return &ast.ArrayType{Elt: p.toType(t.X), Lbrack: t.Lbrack, Len: t.Index, Rbrack: t.Rbrack}
+	}
+	p.error(p.offset, "expected type name")
+	return x
+}
+
+// parseVarDecl parses the rest of a local variable declaration after its
+// type.
+func (p *Parser) parseVarDecl(typ ast.Expr) *ast.VariableDeclaration {
+	decl := &ast.VariableDeclaration{Typ: typ}
+	if p.tok == token.PAYABLE {
+		if t, ok := typ.(*ast.ElementaryType); ok && t.Name == "address" {
+			t.Payable = p.offset
+			p.next()
+			decl.Typ = p.parseArrayType(t)
+		} else {
+			p.errorExpected(p.offset, "variable name")
+			p.next()
+		}
+	}
+	switch p.tok {
+	case token.MEMORY, token.STORAGE, token.CALLDATA:
+		decl.Location = p.parseKeyword()
+	}
+	decl.Name = p.parseIdent()
+	return decl
+}
+
+// parseVarDeclStmt parses the rest of `T x = v;` after the type.
+func (p *Parser) parseVarDeclStmt(typ ast.Expr) *ast.VariableDeclarationStmt {
+	stmt := &ast.VariableDeclarationStmt{Vars: []*ast.VariableDeclaration{p.parseVarDecl(typ)}}
+	if p.tok == token.ASSIGN {
+		p.next()
+		stmt.Value = p.parseExpr()
+	}
+	stmt.Semicolon = p.expectStmtSemi()
+	return stmt
+}
+
+// parseTupleStmt parses a statement starting with '(': a tuple declaration
+// like `(uint a, , bool c) = f();`, or an expression statement like
+// `(a, b) = (b, a);`.
+func (p *Parser) parseTupleStmt() ast.Stmt {
+	lparen := p.expect(token.LPAREN)
+	var elts []ast.Expr
+	var starts []token.Pos
+	var vars []*ast.VariableDeclaration
+	isDecl := false
+	for p.tok != token.RPAREN && p.tok != token.EOF {
+		var x ast.Expr
+		var v *ast.VariableDeclaration
+		starts = append(starts, p.offset)
+		if p.tok != token.COMMA {
+			x = p.parseExpr()
+			if isVarDeclStart(p.tok) {
+				v = p.parseVarDecl(p.toType(x))
+				isDecl = true
+			}
+		}
+		elts = append(elts, x)
+		vars = append(vars, v)
+		if p.tok != token.COMMA {
 			break
 		}
-		functionDef.Block = append(functionDef.Block, expr)
+		p.next()
+		if p.tok == token.RPAREN {
+			// trailing omitted component
+			elts = append(elts, nil)
+			starts = append(starts, p.offset)
+			vars = append(vars, nil)
+		}
+	}
+	rparen := p.expect(token.RPAREN)
+
+	if !isDecl {
+		x := p.parsePrimaryExpr(tupleExpr(lparen, elts, rparen))
+		return p.parseExprStmt(lparen, p.parseExprFrom(x))
+	}
+	for i, v := range vars {
+		if v == nil && elts[i] != nil {
+			p.error(starts[i], "expected variable declaration")
+		}
+	}
+	stmt := &ast.VariableDeclarationStmt{Lparen: lparen, Vars: vars, Rparen: rparen}
+	p.expect(token.ASSIGN)
+	stmt.Value = p.parseExpr()
+	stmt.Semicolon = p.expectStmtSemi()
+	return stmt
+}
+
+// tupleExpr returns a ParenExpr for a single parenthesized expression and
+// a TupleExpr otherwise.
+func tupleExpr(lparen token.Pos, elts []ast.Expr, rparen token.Pos) ast.Expr {
+	if len(elts) == 1 && elts[0] != nil {
+		return &ast.ParenExpr{Lparen: lparen, X: elts[0], Rparen: rparen}
+	}
+	return &ast.TupleExpr{Lparen: lparen, Elts: elts, Rparen: rparen}
+}
+
+// parseExprStmt parses the rest of an expression statement starting at
+// from after the expression x.
+func (p *Parser) parseExprStmt(from token.Pos, x ast.Expr) ast.Stmt {
+	pos := p.offset
+	if p.tok != token.SEMICOLON {
+		p.errorExpected(pos, "';'")
+		if p.tok == token.RBRACE || p.tok == token.EOF || declStart[p.tok] {
+			// only the ';' is missing
+			return &ast.ExprStmt{X: x, Semicolon: pos}
+		}
+		p.skip(declStart)
+		return &ast.BadStmt{From: from, To: p.offset}
 	}
+	p.next()
+	return &ast.ExprStmt{X: x, Semicolon: pos}
+}
 
-	return functionDef, nil
+// expectStmtSemi expects the ';' terminating a statement and skips the rest
+// of the statement if it is missing. Like expect, it returns the position
+// of the current token.
+func (p *Parser) expectStmtSemi() token.Pos {
+	pos := p.offset
+	if p.tok != token.SEMICOLON {
+		p.errorExpected(pos, "';'")
+		if p.tok != token.RBRACE {
+			p.skip(declStart)
+		}
+		return pos
+	}
+	p.next()
+	return pos
 }
 
-func (p *Parser) parseBinaryExpr() ast.Expr {
-	if p.tok == '0' {
-		return nil
+// parseCond parses a parenthesized condition.
+func (p *Parser) parseCond() ast.Expr {
+	p.expect(token.LPAREN)
+	x := p.parseExpr()
+	p.expect(token.RPAREN)
+	return x
+}
+
+// parseCallExpr parses a call of an event or an error.
+func (p *Parser) parseCallExpr(callType string) ast.Expr {
+	x := p.parsePrimaryExpr(nil)
+	if call, ok := x.(*ast.CallExpr); ok {
+		return call
 	}
+	p.errorExpected(x.Pos(), callType)
+	return &ast.BadExpr{From: x.Pos(), To: x.End()}
+}
+
+func (p *Parser) parseIfStmt() *ast.IfStmt {
+	stmt := &ast.IfStmt{If: p.expect(token.IF)}
+	stmt.Cond = p.parseCond()
+	stmt.Body = p.parseStmt()
+	if p.tok == token.ELSE {
+		stmt.ElsePos = p.offset
+		p.next()
+		stmt.Else = p.parseStmt()
+	}
+	return stmt
+}
+
+func (p *Parser) parseForStmt() *ast.ForStmt {
+	stmt := &ast.ForStmt{For: p.expect(token.FOR)}
+	p.expect(token.LPAREN)
 	if p.tok == token.SEMICOLON {
 		p.next()
-		return nil
+	} else {
+		stmt.Init = p.parseSimpleStmt()
+	}
+	if p.tok != token.SEMICOLON {
+		stmt.Cond = p.parseExpr()
+	}
+	p.expect(token.SEMICOLON)
+	if p.tok != token.RPAREN {
+		stmt.Post = p.parseExpr()
 	}
+	p.expect(token.RPAREN)
+	stmt.Body = p.parseStmt()
+	return stmt
+}
 
-	x := p.parseUnaryExpr()
+func (p *Parser) parseTryStmt() *ast.TryStmt {
+	stmt := &ast.TryStmt{Try: p.expect(token.TRY)}
+	stmt.Call = p.parseExpr()
+	if p.tok == token.RETURNS {
+		p.next()
+		stmt.Returns = p.parseParameterList()
+	}
+	stmt.Body = p.parseBlockStmt()
+	for p.tok == token.CATCH {
+		clause := &ast.CatchClause{Catch: p.offset}
+		p.next()
+		if p.tok == token.IDENT {
+			clause.Name = p.parseIdent()
+		}
+		if p.tok == token.LPAREN {
+			clause.Params = p.parseParameterList()
+		}
+		clause.Body = p.parseBlockStmt()
+		stmt.Catches = append(stmt.Catches, clause)
+	}
+	if len(stmt.Catches) == 0 {
+		p.errorExpected(p.offset, "'catch'")
+	}
+	return stmt
+}
+
+func (p *Parser) parseAssemblyStmt() *ast.AssemblyStmt {
+	stmt := &ast.AssemblyStmt{Assembly: p.expect(token.ASSEMBLY)}
+	if p.tok == token.STRING {
+		stmt.Dialect = p.parseBasicLit().(*ast.BasicLit)
+	}
+	if p.tok == token.LPAREN {
+		p.next()
+		for p.tok == token.STRING {
+			stmt.Flags = append(stmt.Flags, p.parseBasicLit().(*ast.BasicLit))
+			if p.tok != token.COMMA {
+				break
+			}
+			p.next()
+		}
+		p.expect(token.RPAREN)
+	}
+	if p.tok != token.LBRACE {
+		// an empty body at the error, like parseBlockStmt's
+		p.errorExpected(p.offset, "'{'")
+		stmt.Body = &yul.Block{Lbrace: p.offset, Rbrace: p.offset}
+		return stmt
+	}
+	// the Yul parser scans the block itself; continue after it
+	var end token.Pos
+	stmt.Body, end = yul.ParseBlock(p.file, p.src, p.file.Offset(p.offset), p.errorAt)
+	p.scanner.Seek(end)
+	p.next()
+	return stmt
+}
+
+// ----------------------------------------------------------------------------
+// Expressions
+
+func (p *Parser) parseExpr() ast.Expr {
+	return p.parseExprFrom(nil)
+}
+
+// parseExprFrom parses an expression including conditional and assignment
+// expressions. If x is not nil, it is the already parsed left-most unary
+// expression.
+func (p *Parser) parseExprFrom(x ast.Expr) ast.Expr {
+	x = p.parseBinaryExpr(x, token.LowestPrec+1)
+	if p.tok == token.QUESTION {
+		cond := &ast.ConditionalExpr{Cond: x, Question: p.offset}
+		p.next()
+		cond.Then = p.parseExpr()
+		cond.Colon = p.expect(token.COLON)
+		cond.Else = p.parseExpr()
+		x = cond
+	}
+	if p.tok.IsAssignOp() {
+		// assignments are right-associative
+		op := p.tok
+		opPos := p.offset
+		p.next()
+		y := p.parseExpr()
+		return &ast.BinaryExpr{
+			X:     x,
+			Op:    op,
+			OpPos: opPos,
+			Y:     y,
+		}
+	}
+	return x
+}
+
+// parseBinaryExpr parses a binary expression whose operators have at least
+// precedence prec1. If x is not nil, it is the already parsed left-most
+// unary expression.
+func (p *Parser) parseBinaryExpr(x ast.Expr, prec1 int) ast.Expr {
 	if x == nil {
-		return nil
+		x = p.parseUnaryExpr()
 	}
 	for {
-		if p.tok == '0' {
+		op := p.tok
+		oprec := op.Precedence()
+		if oprec < prec1 {
 			return x
 		}
-		if p.tok == token.SEMICOLON {
-			return x
+		opPos := p.offset
+		p.next()
+		var y ast.Expr
+		if op == token.POW {
+			// ** is right-associative
+			y = p.parseBinaryExpr(nil, oprec)
+		} else {
+			y = p.parseBinaryExpr(nil, oprec+1)
 		}
-		switch p.tok {
-		case token.ASSIGN, token.ADD, token.MUL, token.POW:
-			// save tok/pos for op
-			op := p.tok
-			opPos := p.offset
-			p.next()
-			y := p.parseBinaryExpr()
-			x = &ast.BinaryExpr{
-				X:     x,
-				Op:    op,
-				OpPos: opPos,
-				Y:     y,
-			}
-		default:
-			return x
+		x = &ast.BinaryExpr{
+			X:     x,
+			Op:    op,
+			OpPos: opPos,
+			Y:     y,
 		}
 	}
 }
 
 func (p *Parser) parseUnaryExpr() ast.Expr {
-	// NOTE: unary expr is not supported yet
-	return p.parsePrimaryExpr()
-}
-
-func (p *Parser) parsePrimaryExpr() ast.Expr {
-	// NOTE: primary expr is not supported yet
-	x := p.parseOperand()
 	switch p.tok {
-	case token.PERIOD:
+	case token.SUB, token.NOT, token.TILDE, token.INC, token.DEC, token.DELETE:
+		op := p.tok
+		opPos := p.offset
 		p.next()
+		x := p.parseUnaryExpr()
+		return &ast.UnaryExpr{OpPos: opPos, Op: op, X: x}
+	}
+	return p.parsePrimaryExpr(nil)
+}
+
+// parsePrimaryExpr parses an operand and its suffixes. If x is not nil, it
+// is the already parsed operand.
+func (p *Parser) parsePrimaryExpr(x ast.Expr) ast.Expr {
+	if x == nil {
+		x = p.parseOperand()
+	}
+	for {
 		switch p.tok {
-		case token.IDENT:
+		case token.PERIOD:
+			p.next()
 			x = p.parseSelector(x)
+		case token.LPAREN:
+			x = p.parseCallOrConversion(x)
+		case token.LBRACK:
+			x = p.parseIndexExpr(x)
+		case token.LBRACE:
+			if _, ok := x.(*ast.CallExpr); ok {
+				// a block following a call, as in `try f() {`
+				return x
+			}
+			x = &ast.CallOptions{X: x, Options: p.parseNamedArgList()}
+		case token.INC, token.DEC:
+			x = &ast.UnaryExpr{OpPos: p.offset, Op: p.tok, X: x, Postfix: true}
+			p.next()
+		default:
+			return x
 		}
-	case token.LPAREN:
-		x = p.parseCallOrConversion(x)
-	case token.LBRACK:
-		x = p.parseIndexExpr(x)
 	}
-	return x
 }
 
-func (p *Parser) parseCallOrConversion(x ast.Expr) ast.Expr {
-	var args []ast.Expr
-	p.expect(token.LPAREN)
-	lparen := p.offset
-	p.next()
-	for p.tok != token.RPAREN && p.tok != 0 {
-		args = append(args, p.parseUnaryExpr())
+func (p *Parser) parseCallOrConversion(x ast.Expr) *ast.CallExpr {
+	call := &ast.CallExpr{Fun: x}
+	call.Lparen = p.expect(token.LPAREN)
+	if p.tok == token.LBRACE {
+		call.NamedArgs = p.parseNamedArgList()
+	} else {
+		for p.tok != token.RPAREN && p.tok != token.EOF {
+			call.Args = append(call.Args, p.parseExpr())
+			if p.tok != token.COMMA {
+				break
+			}
+			p.next()
+		}
+	}
+	call.Rparen = p.expect(token.RPAREN)
+	return call
+}
+
+// parseNamedArgList parses named arguments or call options like
+// `{value: 1, gas: 2}`.
+func (p *Parser) parseNamedArgList() *ast.NamedArgList {
+	list := &ast.NamedArgList{}
+	list.Lbrace = p.expect(token.LBRACE)
+	for p.tok != token.RBRACE && p.tok != token.EOF {
+		arg := &ast.NamedArg{Name: p.parseIdent()}
+		arg.Colon = p.expect(token.COLON)
+		arg.Value = p.parseExpr()
+		list.List = append(list.List, arg)
+		if p.tok != token.COMMA {
+			break
+		}
 		p.next()
 	}
-	p.expect(token.RPAREN)
-	rparen := p.offset
-	return &ast.CallExpr{Fun: x, Lparen: lparen, Args: args, Rparen: rparen}
+	list.Rbrace = p.expect(token.RBRACE)
+	return list
 }
 
 func (p *Parser) parseSelector(x ast.Expr) ast.Expr {
@@ -278,42 +1417,122 @@ func (p *Parser) parseSelector(x ast.Expr) ast.Expr {
 	return &ast.SelectorExpr{X: x, Sel: sel}
 }
 
+// parseIndexExpr parses an index like `x[i]` or an index range like
+// `x[1:4]`.
 func (p *Parser) parseIndexExpr(x ast.Expr) ast.Expr {
-	idxExpr := &ast.IndexExpr{}
-	idxExpr.X = x
-
-	p.expect(token.LBRACK)
-	idxExpr.Rbrack = p.offset
-	p.next()
-	idxExpr.Index = p.parseBinaryExpr()
-
-	p.expect(token.RBRACK)
-	idxExpr.Rbrack = p.offset
-	p.next()
-
-	return idxExpr
+	lbrack := p.expect(token.LBRACK)
+	var index ast.Expr
+	if p.tok != token.RBRACK && p.tok != token.COLON {
+		index = p.parseExpr()
+	}
+	if p.tok == token.COLON {
+		rng := &ast.IndexRangeExpr{X: x, Lbrack: lbrack, Low: index, Colon: p.offset}
+		p.next()
+		if p.tok != token.RBRACK {
+			rng.High = p.parseExpr()
+		}
+		rng.Rbrack = p.expect(token.RBRACK)
+		return rng
+	}
+	rbrack := p.expect(token.RBRACK)
+	return &ast.IndexExpr{X: x, Lbrack: lbrack, Index: index, Rbrack: rbrack}
 }
 
 func (p *Parser) parseOperand() ast.Expr {
 	switch p.tok {
-	case token.IDENT:
+	case token.IDENT, token.BYTE:
+		if isElementaryType(p.lit) {
+			// a type in a conversion like `uint256(x)`
+			typ := &ast.ElementaryType{NamePos: p.offset, Name: p.lit}
+			p.next()
+			return typ
+		}
 		return p.parseIdent()
-	case token.INT, token.STRING:
+	case token.PAYABLE:
+		// the conversion `payable(x)`
+		return p.parseKeyword()
+	case token.INT, token.RATIONAL, token.STRING, token.HEX_STRING, token.UNICODE_STRING, token.TRUE, token.FALSE:
 		return p.parseBasicLit()
 	case token.LPAREN:
+		return p.parseTupleExpr()
+	case token.LBRACK:
+		return p.parseArrayLit()
+	case token.NEW:
+		x := &ast.NewExpr{New: p.offset}
 		p.next()
-		x := p.parseBinaryExpr()
-		p.expect(token.RPAREN)
-		rparen := p.offset
+		x.Typ = p.parseType()
+		return x
+	case token.TYPE:
+		x := &ast.MetaTypeExpr{Type: p.offset}
+		p.next()
+		x.Lparen = p.expect(token.LPAREN)
+		x.Typ = p.parseType()
+		x.Rparen = p.expect(token.RPAREN)
+		return x
+	}
+	pos := p.offset
+	p.errorExpected(pos, "operand")
+	if p.tok.IsKeyword() && !topLevelStart[p.tok] && !declStart[p.tok] {
+		// skip a keyword in place of the operand, but keep the ones
+		// starting declarations to resynchronize at
+		end := pos + token.Pos(utf8.RuneCountInString(p.lit))
 		p.next()
-		return &ast.ParenExpr{Lparen: p.offset, X: x, Rparen: rparen}
-	case token.RBRACE: // hotfix
-		return nil
+		return &ast.BadExpr{From: pos, To: end}
 	}
-	panic("parseOperand: BadExpr: " + string(p.tok) + p.lit)
+	return &ast.BadExpr{From: pos, To: pos}
+}
+
+// parseTupleExpr parses a parenthesized expression or a tuple.
+func (p *Parser) parseTupleExpr() ast.Expr {
+	lparen := p.expect(token.LPAREN)
+	var elts []ast.Expr
+	for p.tok != token.RPAREN && p.tok != token.EOF {
+		var x ast.Expr
+		if p.tok != token.COMMA {
+			x = p.parseExpr()
+		}
+		elts = append(elts, x)
+		if p.tok != token.COMMA {
+			break
+		}
+		p.next()
+		if p.tok == token.RPAREN {
+			elts = append(elts, nil)
+		}
+	}
+	rparen := p.expect(token.RPAREN)
+	return tupleExpr(lparen, elts, rparen)
+}
+
+// parseArrayLit parses an inline array like `[1, 2, 3]`.
+func (p *Parser) parseArrayLit() *ast.ArrayLit {
+	lit := &ast.ArrayLit{}
+	lit.Lbrack = p.expect(token.LBRACK)
+	for p.tok != token.RBRACK && p.tok != token.EOF {
+		lit.Elts = append(lit.Elts, p.parseExpr())
+		if p.tok != token.COMMA {
+			break
+		}
+		p.next()
+	}
+	lit.Rbrack = p.expect(token.RBRACK)
+	return lit
 }
 
 func (p *Parser) parseIdent() *ast.Ident {
+	pos := p.offset
+	name := "_"
+	if p.tok == token.IDENT {
+		name = p.lit
+		p.next()
+	} else {
+		p.errorExpected(pos, "identifier")
+	}
+	return &ast.Ident{Name: name, NamePos: pos}
+}
+
+// parseKeyword parses the current keyword as an identifier.
+func (p *Parser) parseKeyword() *ast.Ident {
 	name := &ast.Ident{Name: p.lit, NamePos: p.offset}
 	p.next()
 	return name
@@ -322,17 +1541,14 @@ func (p *Parser) parseIdent() *ast.Ident {
 func (p *Parser) parseBasicLit() ast.Expr {
 	lit := &ast.BasicLit{Kind: p.tok, Value: p.lit, ValuePos: p.offset}
 	p.next()
-	return lit
-}
-
-func (p *Parser) expect(expect token.Token) (token.Token, error) {
-	if p.tok != expect {
-		return 0, fmt.Errorf("expect token %v but got %v", expect, p.tok)
+	switch lit.Kind {
+	case token.INT, token.RATIONAL:
+		if p.tok.IsUnit() {
+			lit.Unit = p.parseKeyword()
+		}
+		lit.Number = numberValue(lit)
+	case token.STRING, token.HEX_STRING, token.UNICODE_STRING:
+		lit.Text = stringValue(lit.Value)
 	}
-	return p.tok, nil
-}
-
-func (p *Parser) expectNext(expect token.Token) (token.Token, error) {
-	p.next()
-	return p.expect(expect)
+	return lit
 }
@@ -1,15 +1,30 @@
 package parser
 
 import (
+	"fmt"
+	"math/big"
 	"testing"
 
 	"github.com/ToQoz/gopwt/assert"
 	"github.com/blockchain-labs-org/solzaemon/ast"
+	"github.com/blockchain-labs-org/solzaemon/scanner"
 	"github.com/blockchain-labs-org/solzaemon/token"
 )
 
+func parse(src string) (*ast.Program, error) {
+	_, got, err := parseFile(src)
+	return got, err
+}
+
+func parseFile(src string) (*token.File, *ast.Program, error) {
+	runes := []rune(src)
+	f := token.NewFileSet().AddFile("", -1, len(runes))
+	got, err := Parse(f, runes)
+	return f, got, err
+}
+
 func TestParseERC20SimpleToken(t *testing.T) {
-	got, err := Parse(token.NewFile(), []rune(`pragma solidity ^0.4.23;
+	got, err := parse(`pragma solidity ^0.4.23;
 import "../token/ERC20/StandardToken.sol";
 
 contract SimpleToken is StandardToken {
@@ -22,41 +37,41 @@ contract SimpleToken is StandardToken {
 		totalSupply_ = INITIAL_SUPPLY;
 		balances[msg.sender] = INITIAL_SUPPLY;
 	}
-}`))
+}`)
 	assert.Require(t, err == nil)
 	// pragma
-	assert.OK(t, got.PragmaDirective.Name.Name == "solidity")
-	assert.OK(t, got.PragmaDirective.Value == "^0.4.23")
+	assert.OK(t, got.PragmaDirectives[0].Name.Name == "solidity")
+	assert.OK(t, got.PragmaDirectives[0].Value == "^0.4.23")
 	// imports
 	assert.Require(t, len(got.ImportDirectives) == 1)
-	assert.OK(t, got.ImportDirectives[0].Path == `"../token/ERC20/StandardToken.sol"`)
+	assert.OK(t, got.ImportDirectives[0].Path == "../token/ERC20/StandardToken.sol")
 	// contract
 	assert.Require(t, len(got.ContractDefinition) == 1)
 	// contract/state-vars
 	// contract/state-vars/1
 	assert.Require(t, len(got.ContractDefinition[0].StateVariableDeclarations) == 4)
-	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[0].Typ.Name == "string")
+	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[0].Typ.(*ast.ElementaryType).Name == "string")
 	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[0].Visibility == "public")
 	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[0].IsConstant == true)
 	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[0].Name.Name == "name")
 	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[0].Rhs.(*ast.BasicLit).Value == `"SimpleToken"`)
 	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[0].Rhs.(*ast.BasicLit).Kind == token.STRING)
 	// contract/state-vars/2
-	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[1].Typ.Name == "string")
+	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[1].Typ.(*ast.ElementaryType).Name == "string")
 	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[1].Visibility == "public")
 	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[1].IsConstant == true)
 	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[1].Name.Name == "symbol")
 	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[1].Rhs.(*ast.BasicLit).Value == `"SIM"`)
 	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[1].Rhs.(*ast.BasicLit).Kind == token.STRING)
 	// contract/state-vars/3
-	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[2].Typ.Name == "uint8")
+	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[2].Typ.(*ast.ElementaryType).Name == "uint8")
 	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[2].Visibility == "public")
 	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[2].IsConstant == true)
 	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[2].Name.Name == "decimals")
 	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[2].Rhs.(*ast.BasicLit).Kind == token.INT)
 	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[2].Rhs.(*ast.BasicLit).Value == `18`)
 	// contract/state-vars/4
-	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[3].Typ.Name == "uint256")
+	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[3].Typ.(*ast.ElementaryType).Name == "uint256")
 	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[3].Visibility == "public")
 	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[3].IsConstant == true)
 	assert.OK(t, got.ContractDefinition[0].StateVariableDeclarations[3].Name.Name == "INITIAL_SUPPLY")
@@ -70,3 +85,1309 @@ contract SimpleToken is StandardToken {
 	// contract/function-defs
 	assert.Require(t, len(got.ContractDefinition[0].FunctionDefinitions) == 1)
 }
+
+func TestParseNumberLiterals(t *testing.T) {
+	got, err := parse(`contract C {
+	uint a = 0x1_f;
+	uint b = 2.5 ether;
+	uint c = 1e18;
+	uint d = 1_000 days;
+	uint e = .5e-1;
+	uint f = 1e99999;
+}`)
+	assert.Require(t, err == nil)
+	vars := got.ContractDefinition[0].StateVariableDeclarations
+	assert.Require(t, len(vars) == 6)
+	lit := func(i int) *ast.BasicLit { return vars[i].Rhs.(*ast.BasicLit) }
+
+	assert.OK(t, lit(0).Kind == token.INT)
+	assert.OK(t, lit(0).Value == "0x1_f")
+	assert.OK(t, lit(0).Unit == nil)
+	assert.OK(t, lit(0).Number.Cmp(big.NewRat(31, 1)) == 0)
+
+	assert.OK(t, lit(1).Kind == token.RATIONAL)
+	assert.OK(t, lit(1).Value == "2.5")
+	assert.OK(t, lit(1).Unit.Name == "ether")
+	assert.OK(t, lit(1).Number.RatString() == "2500000000000000000")
+
+	assert.OK(t, lit(2).Number.RatString() == "1000000000000000000")
+	assert.OK(t, lit(3).Unit.Name == "days")
+	assert.OK(t, lit(3).Number.Cmp(big.NewRat(86400000, 1)) == 0)
+	assert.OK(t, lit(4).Number.Cmp(big.NewRat(1, 20)) == 0)
+	assert.OK(t, lit(5).Number == nil)
+}
+
+func TestParseStringLiterals(t *testing.T) {
+	got, err := parse(`contract C {
+	string a = "a\tb\x41\u00e9\
+c";
+	string b = 'single';
+	string c = unicode"😃";
+	bytes d = hex"00_ff";
+}`)
+	assert.Require(t, err == nil)
+	vars := got.ContractDefinition[0].StateVariableDeclarations
+	assert.Require(t, len(vars) == 4)
+	lit := func(i int) *ast.BasicLit { return vars[i].Rhs.(*ast.BasicLit) }
+
+	assert.OK(t, lit(0).Kind == token.STRING)
+	assert.OK(t, lit(0).Text == "a\tbAéc")
+	assert.OK(t, lit(1).Kind == token.STRING)
+	assert.OK(t, lit(1).Value == "'single'")
+	assert.OK(t, lit(1).Text == "single")
+	assert.OK(t, lit(2).Kind == token.UNICODE_STRING)
+	assert.OK(t, lit(2).Value == `unicode"😃"`)
+	assert.OK(t, lit(2).Text == "😃")
+	assert.OK(t, lit(3).Kind == token.HEX_STRING)
+	assert.OK(t, lit(3).Text == "\x00\xff")
+}
+
+func TestParseErrors(t *testing.T) {
+	got, err := parse(`pragma solidity ^0.4.23;
+
+contract A {
+	uint256 public a = ;
+	uint256 public b = 1
+	function f() public {
+		a = (1 + ;
+		b = a;
+		b = a a;
+	}
+	) garbage;
+	function g() public {
+		b = a
+	}
+}
+
+} contract B is A {
+	function h() public {
+		a = b;
+	}
+}`)
+	assert.Require(t, err != nil)
+	list, ok := err.(scanner.ErrorList)
+	assert.Require(t, ok)
+	assert.Require(t, len(list) == 7)
+	assert.OK(t, list[0].Error() == "4:21: expected operand, found ';'")
+	assert.OK(t, list[1].Error() == "6:2: expected ';', found 'function'")
+	assert.OK(t, list[2].Error() == "7:12: expected operand, found ';'")
+	assert.OK(t, list[3].Error() == "9:9: expected ';', found 'a'")
+	assert.OK(t, list[4].Error() == "11:2: expected contract member declaration, found ')'")
+	assert.OK(t, list[5].Error() == "14:2: expected ';', found '}'")
+	assert.OK(t, list[6].Error() == "17:1: expected pragma, import, contract or declaration, found '}'")
+
+	assert.OK(t, got.PragmaDirectives[0].Value == "^0.4.23")
+	assert.Require(t, len(got.ContractDefinition) == 2)
+	a := got.ContractDefinition[0]
+	assert.Require(t, len(a.StateVariableDeclarations) == 2)
+	assert.OK(t, a.StateVariableDeclarations[0].Name.Name == "a")
+	assert.OK(t, a.StateVariableDeclarations[1].Name.Name == "b")
+	assert.Require(t, len(a.FunctionDefinitions) == 2)
+	assert.Require(t, len(a.FunctionDefinitions[0].Body.List) == 3)
+	_, ok = a.FunctionDefinitions[0].Body.List[0].(*ast.ExprStmt).X.(*ast.BinaryExpr).Y.(*ast.ParenExpr).X.(*ast.BinaryExpr).Y.(*ast.BadExpr)
+	assert.OK(t, ok)
+	assert.OK(t, a.FunctionDefinitions[0].Body.List[1].(*ast.ExprStmt).X.(*ast.BinaryExpr).Op == token.ASSIGN)
+	_, ok = a.FunctionDefinitions[0].Body.List[2].(*ast.BadStmt)
+	assert.OK(t, ok)
+	assert.Require(t, len(a.FunctionDefinitions[1].Body.List) == 1)
+	assert.OK(t, a.FunctionDefinitions[1].Body.List[0].(*ast.ExprStmt).X.(*ast.BinaryExpr).Op == token.ASSIGN)
+	b := got.ContractDefinition[1]
+	assert.OK(t, b.Name.Name == "B")
+	assert.Require(t, len(b.FunctionDefinitions) == 1)
+	assert.OK(t, b.FunctionDefinitions[0].Name.Name == "h")
+}
+
+func TestParseErrors_EOF(t *testing.T) {
+	for _, src := range []string{
+		"",
+		"pragma",
+		"import",
+		"contract",
+		"contract A is",
+		"contract A {",
+		"contract A { uint",
+		"contract A { function f(",
+		"contract A { function f() public { a = b[",
+		"contract A { function f() public { g(1, ",
+		")]}",
+	} {
+		got, _ := parse(src)
+		assert.OK(t, got != nil, src)
+	}
+}
+
+func TestParseErrors_KeywordOperands(t *testing.T) {
+	tests := []struct {
+		src string
+		msg string
+	}{
+		{"x = contract + 1;", "3:7: expected operand, found 'contract'"},
+		{"return function;", "3:10: expected operand, found 'function'"},
+		{"x = memory + 1;", "3:7: expected operand, found 'memory'"},
+		{"return while;", "3:10: expected operand, found 'while'"},
+	}
+	for _, tt := range tests {
+		_, err := parse("contract A {\n\tfunction f() public {\n\t\t" + tt.src + "\n\t}\n}")
+		assert.Require(t, err != nil, tt.src)
+		list := err.(scanner.ErrorList)
+		assert.OK(t, list[0].Error() == tt.msg, tt.src)
+	}
+	// other keywords are skipped as the operand
+	_, err := parse("contract A {\n\tfunction f() public {\n\t\tx = memory + 1;\n\t}\n}")
+	assert.OK(t, len(err.(scanner.ErrorList)) == 1)
+
+	got, err := parse(`contract A {
+	function f() public {
+		x = payable(a);
+		y = byte(1);
+	}
+}`)
+	assert.Require(t, err == nil)
+	body := got.ContractDefinition[0].FunctionDefinitions[0].Body
+	x := body.List[0].(*ast.ExprStmt).X.(*ast.BinaryExpr).Y.(*ast.CallExpr)
+	assert.OK(t, x.Fun.(*ast.Ident).Name == "payable")
+	y := body.List[1].(*ast.ExprStmt).X.(*ast.BinaryExpr).Y.(*ast.CallExpr)
+	assert.OK(t, y.Fun.(*ast.ElementaryType).Name == "byte")
+}
+
+func TestParseFunctionParameters(t *testing.T) {
+	got, err := parse(`contract C {
+	function transfer(address to, uint256 value) public returns (bool) {
+		balances[to] = value;
+	}
+	function batch(uint256[] memory amounts, bytes32[4] calldata, Lib.Info storage info) internal returns (uint256 total, bool ok);
+	function empty() external {}
+}`)
+	assert.Require(t, err == nil)
+	fns := got.ContractDefinition[0].FunctionDefinitions
+	assert.Require(t, len(fns) == 3)
+
+	params := fns[0].Params.List
+	assert.Require(t, len(params) == 2)
+	assert.OK(t, params[0].Typ.(*ast.ElementaryType).Name == "address")
+	assert.OK(t, params[0].Location == nil)
+	assert.OK(t, params[0].Name.Name == "to")
+	assert.OK(t, params[1].Typ.(*ast.ElementaryType).Name == "uint256")
+	assert.OK(t, params[1].Name.Name == "value")
+	assert.Require(t, len(fns[0].Returns.List) == 1)
+	assert.OK(t, fns[0].Returns.List[0].Typ.(*ast.ElementaryType).Name == "bool")
+	assert.OK(t, fns[0].Returns.List[0].Name == nil)
+	assert.OK(t, fns[0].Visibility == "public")
+	assert.Require(t, len(fns[0].Body.List) == 1)
+
+	params = fns[1].Params.List
+	assert.Require(t, len(params) == 3)
+	assert.OK(t, params[0].Typ.(*ast.ArrayType).Elt.(*ast.ElementaryType).Name == "uint256")
+	assert.OK(t, params[0].Typ.(*ast.ArrayType).Len == nil)
+	assert.OK(t, params[0].Location.Name == "memory")
+	assert.OK(t, params[0].Name.Name == "amounts")
+	assert.OK(t, params[1].Typ.(*ast.ArrayType).Len.(*ast.BasicLit).Value == "4")
+	assert.OK(t, params[1].Location.Name == "calldata")
+	assert.OK(t, params[1].Name == nil)
+	path := params[2].Typ.(*ast.UserDefinedType).Path
+	assert.Require(t, len(path) == 2)
+	assert.OK(t, path[0].Name == "Lib" && path[1].Name == "Info")
+	assert.OK(t, params[2].Location.Name == "storage")
+	assert.OK(t, params[2].Name.Name == "info")
+	assert.Require(t, len(fns[1].Returns.List) == 2)
+	assert.OK(t, fns[1].Returns.List[0].Name.Name == "total")
+	assert.OK(t, fns[1].Returns.List[1].Name.Name == "ok")
+	assert.OK(t, fns[1].Visibility == "internal")
+	assert.OK(t, fns[1].Body == nil)
+
+	assert.OK(t, len(fns[2].Params.List) == 0)
+	assert.OK(t, fns[2].Params.Lparen+1 == fns[2].Params.Rparen)
+	assert.OK(t, fns[2].Returns == nil)
+}
+
+func TestParseFunctionParameters_Errors(t *testing.T) {
+	got, err := parse(`contract C {
+	function f(uint256 a, = b) public {
+		a = 1;
+	}
+	function g(uint256 a {
+		a = 2;
+	}
+}`)
+	assert.Require(t, err != nil)
+	list := err.(scanner.ErrorList)
+	assert.Require(t, len(list) == 2)
+	assert.OK(t, list[0].Error() == "2:24: expected identifier, found '='")
+	assert.OK(t, list[1].Error() == "5:23: expected ')', found '{'")
+
+	fns := got.ContractDefinition[0].FunctionDefinitions
+	assert.Require(t, len(fns) == 2)
+	assert.OK(t, fns[0].Params.List[0].Name.Name == "a")
+	assert.OK(t, len(fns[0].Body.List) == 1)
+	assert.OK(t, fns[1].Params.List[0].Name.Name == "a")
+	assert.OK(t, len(fns[1].Body.List) == 1)
+}
+
+func TestParseFunctionSpecifiers(t *testing.T) {
+	got, err := parse(`contract C is A, B {
+	function f(uint256 x) external view virtual override(A, B) onlyOwner whenAbove(x, 1) returns (uint256) {
+		x = 1;
+	}
+	function g() public payable override {}
+	function h() pure Lib.guard internal;
+	function total() constant returns (uint256);
+	constructor(uint256 supply) A(supply) B public {}
+}`)
+	assert.Require(t, err == nil)
+	fns := got.ContractDefinition[0].FunctionDefinitions
+	assert.Require(t, len(fns) == 5)
+
+	f := fns[0]
+	assert.OK(t, f.Visibility == "external")
+	assert.OK(t, f.Mutability == "view")
+	assert.OK(t, f.Virtual)
+	assert.Require(t, f.Override != nil)
+	assert.Require(t, len(f.Override.Overrides) == 2)
+	assert.OK(t, f.Override.Overrides[0].(*ast.Ident).Name == "A")
+	assert.OK(t, f.Override.Overrides[1].(*ast.Ident).Name == "B")
+	assert.OK(t, f.Override.Lparen == f.Override.Override+token.Pos(len("override")))
+	assert.Require(t, len(f.Modifiers) == 2)
+	assert.OK(t, f.Modifiers[0].Fun.(*ast.Ident).Name == "onlyOwner")
+	assert.OK(t, f.Modifiers[0].Lparen == token.NoPos)
+	assert.OK(t, f.Modifiers[0].Args == nil)
+	assert.OK(t, f.Modifiers[1].Fun.(*ast.Ident).Name == "whenAbove")
+	assert.OK(t, f.Modifiers[1].Lparen.IsValid())
+	assert.Require(t, len(f.Modifiers[1].Args) == 2)
+	assert.OK(t, f.Modifiers[1].Args[0].(*ast.Ident).Name == "x")
+	assert.OK(t, f.Modifiers[1].Args[1].(*ast.BasicLit).Value == "1")
+	assert.OK(t, f.Modifiers[1].Rparen == f.Modifiers[1].Lparen+token.Pos(len("(x, 1")))
+	assert.Require(t, len(f.Returns.List) == 1)
+	assert.Require(t, len(f.Body.List) == 1)
+
+	g := fns[1]
+	assert.OK(t, g.Visibility == "public")
+	assert.OK(t, g.Mutability == "payable")
+	assert.OK(t, !g.Virtual)
+	assert.Require(t, g.Override != nil)
+	assert.OK(t, g.Override.Lparen == token.NoPos)
+	assert.OK(t, len(g.Override.Overrides) == 0)
+
+	h := fns[2]
+	assert.OK(t, h.Visibility == "internal")
+	assert.OK(t, h.Mutability == "pure")
+	assert.OK(t, h.Override == nil)
+	assert.Require(t, len(h.Modifiers) == 1)
+	assert.OK(t, h.Modifiers[0].Fun.(*ast.SelectorExpr).Sel.(*ast.Ident).Name == "guard")
+
+	assert.OK(t, fns[3].Mutability == "constant")
+	assert.OK(t, fns[3].Visibility == "public")
+	assert.OK(t, fns[3].VisibilityPos == token.NoPos)
+	assert.OK(t, g.VisibilityPos == g.Params.Rparen+2)
+
+	ctor := fns[4]
+	assert.OK(t, ctor.Name.Name == "constructor")
+	assert.Require(t, len(ctor.Modifiers) == 2)
+	assert.OK(t, ctor.Modifiers[0].Fun.(*ast.Ident).Name == "A")
+	assert.OK(t, ctor.Modifiers[0].Args[0].(*ast.Ident).Name == "supply")
+	assert.OK(t, ctor.Modifiers[1].Fun.(*ast.Ident).Name == "B")
+	assert.OK(t, ctor.Visibility == "public")
+}
+
+func TestParseContractMembers(t *testing.T) {
+	got, err := parse(`contract Token {
+	using SafeMath for uint256;
+	using {add, Lib.sub} for Fixed global;
+	using Lib for *;
+
+	struct Account {
+		uint256 balance;
+		mapping(address => uint256) allowances;
+		Lib.Info[] infos;
+	}
+	enum State { Active, Frozen }
+
+	event Transfer(address indexed from, address indexed to, uint256 value);
+	event Log(string) anonymous;
+	error InsufficientBalance(uint256 available, uint256 required);
+
+	address owner;
+
+	modifier onlyOwner {
+		owner = owner;
+		_;
+	}
+	modifier onlyAbove(uint256 amount) virtual;
+
+	function transfer(address to, uint256 value) public onlyOwner {}
+	fallback() external payable {}
+	receive() external payable {}
+	function () payable {}
+}`)
+	assert.Require(t, err == nil)
+	c := got.ContractDefinition[0]
+	assert.Require(t, len(c.Members) == 15)
+	assert.Require(t, len(c.StateVariableDeclarations) == 1)
+	assert.Require(t, len(c.FunctionDefinitions) == 4)
+
+	using := c.Members[0].(*ast.UsingDirective)
+	assert.OK(t, using.Library.(*ast.Ident).Name == "SafeMath")
+	assert.OK(t, using.Typ.(*ast.ElementaryType).Name == "uint256")
+	assert.OK(t, !using.Global)
+	using = c.Members[1].(*ast.UsingDirective)
+	assert.OK(t, using.Library == nil)
+	assert.Require(t, len(using.Functions) == 2)
+	assert.OK(t, using.Functions[1].(*ast.SelectorExpr).Sel.(*ast.Ident).Name == "sub")
+	assert.OK(t, using.Global)
+	using = c.Members[2].(*ast.UsingDirective)
+	assert.OK(t, using.Typ == nil)
+
+	account := c.Members[3].(*ast.StructDefinition)
+	assert.OK(t, account.Name.Name == "Account")
+	assert.Require(t, len(account.Fields) == 3)
+	assert.OK(t, account.Fields[0].Typ.(*ast.ElementaryType).Name == "uint256")
+	assert.OK(t, account.Fields[0].Name.Name == "balance")
+	assert.OK(t, account.Fields[1].Name.Name == "allowances")
+	assert.OK(t, account.Fields[2].Name.Name == "infos")
+
+	state := c.Members[4].(*ast.EnumDefinition)
+	assert.OK(t, state.Name.Name == "State")
+	assert.Require(t, len(state.Values) == 2)
+	assert.OK(t, state.Values[1].Name == "Frozen")
+
+	transfer := c.Members[5].(*ast.EventDefinition)
+	assert.OK(t, transfer.Name.Name == "Transfer")
+	assert.Require(t, len(transfer.Params.List) == 3)
+	assert.OK(t, transfer.Params.List[0].Indexed.IsValid())
+	assert.OK(t, transfer.Params.List[0].Name.Name == "from")
+	assert.OK(t, !transfer.Params.List[2].Indexed.IsValid())
+	assert.OK(t, !transfer.Anonymous)
+	assert.OK(t, c.Members[6].(*ast.EventDefinition).Anonymous)
+
+	insufficient := c.Members[7].(*ast.ErrorDefinition)
+	assert.OK(t, insufficient.Name.Name == "InsufficientBalance")
+	assert.OK(t, len(insufficient.Params.List) == 2)
+
+	assert.OK(t, c.Members[8].(*ast.StateVariableDeclaration).Name.Name == "owner")
+
+	onlyOwner := c.Members[9].(*ast.ModifierDefinition)
+	assert.OK(t, onlyOwner.Name.Name == "onlyOwner")
+	assert.OK(t, onlyOwner.Params == nil)
+	assert.OK(t, len(onlyOwner.Body.List) == 2)
+	onlyAbove := c.Members[10].(*ast.ModifierDefinition)
+	assert.OK(t, len(onlyAbove.Params.List) == 1)
+	assert.OK(t, onlyAbove.Virtual)
+	assert.OK(t, onlyAbove.Body == nil)
+
+	assert.OK(t, c.Members[11].(*ast.FunctionDefinition).Name.Name == "transfer")
+	assert.OK(t, c.Members[12].(*ast.FunctionDefinition).Name.Name == "fallback")
+	assert.OK(t, c.Members[12].(*ast.FunctionDefinition).Mutability == "payable")
+	assert.OK(t, c.Members[13].(*ast.FunctionDefinition).Name.Name == "receive")
+	assert.OK(t, c.Members[14].(*ast.FunctionDefinition).Name == nil)
+}
+
+func TestParseSourceUnits(t *testing.T) {
+	got, err := parse(`pragma solidity ^0.8.8;
+import "./IERC20.sol";
+
+type Price is uint128;
+uint256 constant MAX_SUPPLY = 1000;
+error Unauthorized();
+struct Point { uint256 x; uint256 y; }
+enum Color { Red }
+function add(uint256 a, uint256 b) pure returns (uint256) {
+	a = a + b;
+}
+using {add} for uint256 global;
+
+interface IToken {
+	function totalSupply() external view returns (uint256);
+}
+
+library Math {
+	function max(uint256 a, uint256 b) internal pure returns (uint256) {}
+}
+
+abstract contract Base is IToken {
+	type Id is bytes32;
+}
+
+contract Token is Base(1), Lib.Owned {
+	address immutable owner;
+}
+`)
+	assert.Require(t, err == nil)
+	assert.Require(t, len(got.Units) == 13)
+	assert.OK(t, got.Units[0].(*ast.PragmaDirective) == got.PragmaDirectives[0])
+	assert.OK(t, got.Units[1].(*ast.ImportDirective) == got.ImportDirectives[0])
+
+	price := got.Units[2].(*ast.UserDefinedValueTypeDefinition)
+	assert.OK(t, price.Name.Name == "Price")
+	assert.OK(t, price.Underlying.(*ast.ElementaryType).Name == "uint128")
+	maxSupply := got.Units[3].(*ast.StateVariableDeclaration)
+	assert.OK(t, maxSupply.Name.Name == "MAX_SUPPLY")
+	assert.OK(t, maxSupply.IsConstant)
+	assert.OK(t, got.Units[4].(*ast.ErrorDefinition).Name.Name == "Unauthorized")
+	assert.OK(t, len(got.Units[5].(*ast.StructDefinition).Fields) == 2)
+	assert.OK(t, got.Units[6].(*ast.EnumDefinition).Name.Name == "Color")
+	add := got.Units[7].(*ast.FunctionDefinition)
+	assert.OK(t, add.Name.Name == "add")
+	assert.OK(t, add.Mutability == "pure")
+	assert.OK(t, len(add.Body.List) == 1)
+	assert.OK(t, got.Units[8].(*ast.UsingDirective).Global)
+
+	assert.Require(t, len(got.ContractDefinition) == 4)
+	for i, c := range got.ContractDefinition {
+		assert.OK(t, got.Units[9+i].(*ast.ContractPart) == c)
+	}
+	iToken := got.ContractDefinition[0]
+	assert.OK(t, iToken.Kind == token.INTERFACE)
+	assert.OK(t, !iToken.Abstract)
+	assert.OK(t, len(iToken.FunctionDefinitions) == 1)
+	math := got.ContractDefinition[1]
+	assert.OK(t, math.Kind == token.LIBRARY)
+	assert.OK(t, math.Name.Name == "Math")
+	base := got.ContractDefinition[2]
+	assert.OK(t, base.Kind == token.CONTRACT)
+	assert.OK(t, base.Abstract)
+	assert.OK(t, base.Inherits[0].Name == "IToken")
+	assert.OK(t, base.Bases[0].Lparen == token.NoPos)
+	assert.OK(t, base.Members[0].(*ast.UserDefinedValueTypeDefinition).Name.Name == "Id")
+	tok := got.ContractDefinition[3]
+	assert.OK(t, tok.Kind == token.CONTRACT)
+	assert.OK(t, !tok.Abstract)
+	assert.Require(t, len(tok.Bases) == 2)
+	assert.OK(t, len(tok.Bases[0].Args) == 1)
+	assert.OK(t, tok.Bases[1].Fun.(*ast.SelectorExpr).Sel.(*ast.Ident).Name == "Owned")
+	// only plain names are inherits
+	assert.Require(t, len(tok.Inherits) == 1)
+	assert.OK(t, tok.Inherits[0] == tok.Bases[0].Fun)
+	owner := tok.Members[0].(*ast.StateVariableDeclaration)
+	assert.OK(t, owner.Immutable)
+	assert.OK(t, !owner.IsConstant)
+}
+
+func TestParseStatements(t *testing.T) {
+	got, err := parse(`contract C {
+	function f(uint256 n) public returns (uint256) {
+		if (n == 0) return 1; else { n = n - 1; }
+		for (i = 0; i < n; i += 1) {
+			if (i == 2) continue;
+			break;
+		}
+		for (;;) {}
+		while (n > 0) n -= 1;
+		do { n += 1; } while (n < 10);
+		unchecked { n += 1; }
+		emit Transfer(msg.sender, n);
+		revert InsufficientBalance(n);
+		revert("reason");
+		try token.transfer(to, n) returns (bool ok) {
+			n = 1;
+		} catch Error(string memory reason) {
+		} catch (bytes memory) {
+		} catch {
+		}
+		assembly "evmasm" ("memory-safe") {
+			let x := add(n, 1)
+			if x { revert(0, 0) }
+		}
+		;
+		return;
+	}
+}`)
+	assert.Require(t, err == nil)
+	body := got.ContractDefinition[0].FunctionDefinitions[0].Body
+	assert.Require(t, len(body.List) == 13)
+	assert.OK(t, body.Lbrace.IsValid())
+	assert.OK(t, body.Rbrace > body.Lbrace)
+
+	ifStmt := body.List[0].(*ast.IfStmt)
+	assert.OK(t, ifStmt.Cond.(*ast.BinaryExpr).Op == token.EQ)
+	assert.OK(t, ifStmt.Body.(*ast.ReturnStmt).Result.(*ast.BasicLit).Value == "1")
+	assert.OK(t, ifStmt.Body.End() < ifStmt.ElsePos && ifStmt.ElsePos < ifStmt.Else.Pos())
+	assert.OK(t, len(ifStmt.Else.(*ast.BlockStmt).List) == 1)
+
+	forStmt := body.List[1].(*ast.ForStmt)
+	assert.OK(t, forStmt.Init.(*ast.ExprStmt).X.(*ast.BinaryExpr).Op == token.ASSIGN)
+	assert.OK(t, forStmt.Cond.(*ast.BinaryExpr).Op == token.LSS)
+	assert.OK(t, forStmt.Post.(*ast.BinaryExpr).Op == token.ADD_ASSIGN)
+	loop := forStmt.Body.(*ast.BlockStmt).List
+	assert.Require(t, len(loop) == 2)
+	assert.OK(t, loop[0].(*ast.IfStmt).Body.(*ast.BranchStmt).Tok == token.CONTINUE)
+	assert.OK(t, loop[1].(*ast.BranchStmt).Tok == token.BREAK)
+
+	forever := body.List[2].(*ast.ForStmt)
+	assert.OK(t, forever.Init == nil && forever.Cond == nil && forever.Post == nil)
+
+	whileStmt := body.List[3].(*ast.WhileStmt)
+	assert.OK(t, whileStmt.Cond.(*ast.BinaryExpr).Op == token.GTR)
+	assert.OK(t, whileStmt.Body.(*ast.ExprStmt).X.(*ast.BinaryExpr).Op == token.SUB_ASSIGN)
+
+	doWhile := body.List[4].(*ast.DoWhileStmt)
+	assert.OK(t, doWhile.While > doWhile.Do)
+	assert.OK(t, doWhile.Cond.(*ast.BinaryExpr).Op == token.LSS)
+
+	unchecked := body.List[5].(*ast.UncheckedStmt)
+	assert.OK(t, len(unchecked.Body.List) == 1)
+
+	emit := body.List[6].(*ast.EmitStmt)
+	assert.OK(t, emit.Call.(*ast.CallExpr).Fun.(*ast.Ident).Name == "Transfer")
+	assert.OK(t, len(emit.Call.(*ast.CallExpr).Args) == 2)
+
+	revert := body.List[7].(*ast.RevertStmt)
+	assert.OK(t, revert.Call.(*ast.CallExpr).Fun.(*ast.Ident).Name == "InsufficientBalance")
+	revertCall := body.List[8].(*ast.ExprStmt).X.(*ast.CallExpr)
+	assert.OK(t, revertCall.Fun.(*ast.Ident).Name == "revert")
+	assert.OK(t, revertCall.Args[0].(*ast.BasicLit).Text == "reason")
+
+	try := body.List[9].(*ast.TryStmt)
+	assert.OK(t, try.Call.(*ast.CallExpr).Fun.(*ast.SelectorExpr).Sel.(*ast.Ident).Name == "transfer")
+	assert.OK(t, try.Returns.List[0].Name.Name == "ok")
+	assert.OK(t, len(try.Body.List) == 1)
+	assert.Require(t, len(try.Catches) == 3)
+	assert.OK(t, try.Catches[0].Name.Name == "Error")
+	assert.OK(t, try.Catches[0].Params.List[0].Name.Name == "reason")
+	assert.OK(t, try.Catches[1].Name == nil)
+	assert.OK(t, try.Catches[1].Params.List[0].Location.Name == "memory")
+	assert.OK(t, try.Catches[2].Name == nil && try.Catches[2].Params == nil)
+
+	assembly := body.List[10].(*ast.AssemblyStmt)
+	assert.OK(t, assembly.Dialect.Text == "evmasm")
+	assert.OK(t, assembly.Flags[0].Text == "memory-safe")
+	assert.OK(t, assembly.Body.Rbrace > assembly.Body.Lbrace)
+	assert.OK(t, len(assembly.Body.List) == 2)
+
+	_, ok := body.List[11].(*ast.EmptyStmt)
+	assert.OK(t, ok)
+	assert.OK(t, body.List[12].(*ast.ReturnStmt).Result == nil)
+}
+
+func TestParseStatements_Errors(t *testing.T) {
+	got, err := parse(`contract C {
+	function f() public {
+		emit x;
+		revert a.b;
+	}
+}`)
+	assert.Require(t, err != nil)
+	list := err.(scanner.ErrorList)
+	assert.Require(t, len(list) == 2)
+	assert.OK(t, list[0].Error() == "3:8: expected event call")
+	assert.OK(t, list[1].Error() == "4:10: expected error call")
+	body := got.ContractDefinition[0].FunctionDefinitions[0].Body
+	assert.Require(t, len(body.List) == 2)
+	emit := body.List[0].(*ast.EmitStmt).Call.(*ast.BadExpr)
+	assert.OK(t, emit.To == emit.From+token.Pos(len("x")))
+	revert := body.List[1].(*ast.RevertStmt).Call.(*ast.BadExpr)
+	assert.OK(t, revert.To == revert.From+token.Pos(len("a.b")))
+}
+
+// exprString returns x fully parenthesized.
+func exprString(x ast.Expr) string {
+	switch x := x.(type) {
+	case *ast.Ident:
+		return x.Name
+	case *ast.BasicLit:
+		return x.Value
+	case *ast.ParenExpr:
+		return exprString(x.X)
+	case *ast.BinaryExpr:
+		return "(" + exprString(x.X) + " " + opString[x.Op] + " " + exprString(x.Y) + ")"
+	case *ast.UnaryExpr:
+		if x.Postfix {
+			return "(" + exprString(x.X) + opString[x.Op] + ")"
+		}
+		return "(" + opString[x.Op] + exprString(x.X) + ")"
+	case *ast.ConditionalExpr:
+		return "(" + exprString(x.Cond) + " ? " + exprString(x.Then) + " : " + exprString(x.Else) + ")"
+	case *ast.CallExpr:
+		if x.NamedArgs != nil {
+			return exprString(x.Fun) + "(" + namedArgsString(x.NamedArgs) + ")"
+		}
+		return exprString(x.Fun) + "(" + exprListString(x.Args) + ")"
+	case *ast.CallOptions:
+		return exprString(x.X) + namedArgsString(x.Options)
+	case *ast.IndexExpr:
+		return exprString(x.X) + "[" + exprString(x.Index) + "]"
+	case *ast.IndexRangeExpr:
+		return exprString(x.X) + "[" + exprString(x.Low) + ":" + exprString(x.High) + "]"
+	case *ast.SelectorExpr:
+		return exprString(x.X) + "." + exprString(x.Sel)
+	case *ast.TupleExpr:
+		return "(" + exprListString(x.Elts) + ")"
+	case *ast.ArrayLit:
+		return "[" + exprListString(x.Elts) + "]"
+	case *ast.NewExpr:
+		return "new " + exprString(x.Typ)
+	case *ast.MetaTypeExpr:
+		return "type(" + exprString(x.Typ) + ")"
+	case *ast.ElementaryType:
+		return x.Name
+	case *ast.UserDefinedType:
+		s := x.Path[0].Name
+		for _, id := range x.Path[1:] {
+			s += "." + id.Name
+		}
+		return s
+	case *ast.ArrayType:
+		return exprString(x.Elt) + "[" + exprString(x.Len) + "]"
+	case nil:
+		return ""
+	}
+	return "?"
+}
+
+func exprListString(list []ast.Expr) string {
+	s := ""
+	for i, x := range list {
+		if i > 0 {
+			s += ", "
+		}
+		s += exprString(x)
+	}
+	return s
+}
+
+func namedArgsString(list *ast.NamedArgList) string {
+	s := "{"
+	for i, arg := range list.List {
+		if i > 0 {
+			s += ", "
+		}
+		s += arg.Name.Name + ": " + exprString(arg.Value)
+	}
+	return s + "}"
+}
+
+var opString = map[token.Token]string{
+	token.ADD: "+", token.SUB: "-", token.MUL: "*", token.QUO: "/", token.REM: "%", token.POW: "**",
+	token.AND: "&", token.OR: "|", token.XOR: "^", token.SHL: "<<", token.SHR: ">>", token.SAR: ">>>",
+	token.LAND: "&&", token.LOR: "||", token.EQ: "==", token.NEQ: "!=",
+	token.LSS: "<", token.GTR: ">", token.LEQ: "<=", token.GEQ: ">=",
+	token.ASSIGN: "=", token.ADD_ASSIGN: "+=", token.SUB_ASSIGN: "-=", token.OR_ASSIGN: "|=",
+	token.INC: "++", token.DEC: "--", token.NOT: "!", token.TILDE: "~", token.DELETE: "delete ",
+}
+
+func TestParseExprPrecedence(t *testing.T) {
+	tests := []struct {
+		src, expected string
+	}{
+		{"a + b * c", "(a + (b * c))"},
+		{"a * b + c", "((a * b) + c)"},
+		{"a - b - c", "((a - b) - c)"},
+		{"a ** b ** c", "(a ** (b ** c))"},
+		{"-a ** b", "((-a) ** b)"},
+		{"a * b ** c", "(a * (b ** c))"},
+		{"a << 1 + b", "(a << (1 + b))"},
+		{"a & b ^ c | d", "(((a & b) ^ c) | d)"},
+		{"a | b < c", "((a | b) < c)"},
+		{"a < b == c > d", "((a < b) == (c > d))"},
+		{"a == b && c || d && e", "(((a == b) && c) || (d && e))"},
+		{"a = b = c + 1", "(a = (b = (c + 1)))"},
+		{"a += b * 2", "(a += (b * 2))"},
+		{"a = c ? x + 1 : y", "(a = (c ? (x + 1) : y))"},
+		{"c ? d ? x : y : z", "(c ? (d ? x : y) : z)"},
+		{"c || d ? x : y", "((c || d) ? x : y)"},
+		{"!a && ~b != 0", "((!a) && ((~b) != 0))"},
+		{"i++ + --j", "((i++) + (--j))"},
+		{"delete m[k]", "(delete m[k])"},
+		{"(a + b) * c", "((a + b) * c)"},
+		{"f(a + b, c)[i].d * -e", "(f((a + b), c)[i].d * (-e))"},
+	}
+	for _, tt := range tests {
+		got, err := parse("contract C { function f() public { " + tt.src + "; } }")
+		assert.Require(t, err == nil, tt.src)
+		stmt := got.ContractDefinition[0].FunctionDefinitions[0].Body.List[0].(*ast.ExprStmt)
+		actual := exprString(stmt.X)
+		assert.OK(t, actual == tt.expected, tt.src)
+	}
+}
+
+func TestParseExprSuffixes(t *testing.T) {
+	tests := []struct {
+		src, expected string
+	}{
+		{"a.b.c(d)[e]", "a.b.c(d)[e]"},
+		{"a.b(c).d(e)(f)", "a.b(c).d(e)(f)"},
+		{`msg.sender.call{value: 1}("")`, `msg.sender.call{value: 1}("")`},
+		{"c.f{value: v, gas: g + 1}(x)", "c.f{value: v, gas: (g + 1)}(x)"},
+		{"f({a: 1, b: x})", "f({a: 1, b: x})"},
+		{"f({})", "f({})"},
+		{"x[1:4]", "x[1:4]"},
+		{"msg.data[4:]", "msg.data[4:]"},
+		{"x[:n - 1]", "x[:(n - 1)]"},
+		{"x[:]", "x[:]"},
+		{"new C(1)", "new C(1)"},
+		{"new C{salt: s}(1)", "new C{salt: s}(1)"},
+		{"new uint256[](n)", "new uint256[](n)"},
+		{"new L.S[2][](n)", "new L.S[2][](n)"},
+		{"type(uint8).max + 1", "(type(uint8).max + 1)"},
+		{"type(I).interfaceId", "type(I).interfaceId"},
+		{"(a, b).c", "(a, b).c"},
+		{"[1, x, f()][i]", "[1, x, f()][i]"},
+		{"[[1], [2, 3]]", "[[1], [2, 3]]"},
+	}
+	for _, tt := range tests {
+		got, err := parse("contract C { function f() public { " + tt.src + "; } }")
+		assert.Require(t, err == nil, tt.src)
+		stmt := got.ContractDefinition[0].FunctionDefinitions[0].Body.List[0].(*ast.ExprStmt)
+		actual := exprString(stmt.X)
+		assert.OK(t, actual == tt.expected, tt.src)
+	}
+}
+
+func TestParseExprSuffixes_Positions(t *testing.T) {
+	f, got, err := parseFile(`contract C {
+	function f() public {
+		a.call{value: v}(x[1:4], new D[](n), type(D).min, [y], f({k: z}));
+		try c.g{gas: 1}() {} catch {}
+	}
+}`)
+	assert.Require(t, err == nil)
+	body := got.ContractDefinition[0].FunctionDefinitions[0].Body
+	call := body.List[0].(*ast.ExprStmt).X.(*ast.CallExpr)
+	column := func(pos token.Pos) int { return f.Position(pos).Column }
+
+	opts := call.Fun.(*ast.CallOptions)
+	assert.OK(t, column(opts.Options.Lbrace) == len(`		a.call{`))
+	assert.OK(t, column(opts.Options.List[0].Colon) == len(`		a.call{value:`))
+	assert.OK(t, column(opts.Options.Rbrace) == len(`		a.call{value: v}`))
+	assert.OK(t, column(call.Lparen) == len(`		a.call{value: v}(`))
+
+	rng := call.Args[0].(*ast.IndexRangeExpr)
+	assert.OK(t, column(rng.Lbrack) == len(`		a.call{value: v}(x[`))
+	assert.OK(t, column(rng.Colon) == len(`		a.call{value: v}(x[1:`))
+	assert.OK(t, column(rng.Rbrack) == len(`		a.call{value: v}(x[1:4]`))
+
+	newCall := call.Args[1].(*ast.CallExpr)
+	newExpr := newCall.Fun.(*ast.NewExpr)
+	assert.OK(t, column(newExpr.New) == len(`		a.call{value: v}(x[1:4], n`))
+	assert.OK(t, newExpr.Typ.(*ast.ArrayType).Len == nil)
+
+	meta := call.Args[2].(*ast.SelectorExpr).X.(*ast.MetaTypeExpr)
+	assert.OK(t, column(meta.Type) == len(`		a.call{value: v}(x[1:4], new D[](n), t`))
+	assert.OK(t, column(meta.Rparen) == len(`		a.call{value: v}(x[1:4], new D[](n), type(D)`))
+
+	arr := call.Args[3].(*ast.ArrayLit)
+	assert.OK(t, column(arr.Lbrack) == len(`		a.call{value: v}(x[1:4], new D[](n), type(D).min, [`))
+	assert.OK(t, column(arr.Rbrack) == len(`		a.call{value: v}(x[1:4], new D[](n), type(D).min, [y]`))
+
+	named := call.Args[4].(*ast.CallExpr)
+	assert.OK(t, len(named.Args) == 0)
+	assert.OK(t, named.NamedArgs.List[0].Name.Name == "k")
+	assert.OK(t, column(named.NamedArgs.Rbrace) == len(`		a.call{value: v}(x[1:4], new D[](n), type(D).min, [y], f({k: z}`))
+
+	try := body.List[1].(*ast.TryStmt)
+	assert.OK(t, try.Call.(*ast.CallExpr).Fun.(*ast.CallOptions).Options.List[0].Name.Name == "gas")
+	assert.OK(t, len(try.Catches) == 1)
+}
+
+func TestParseVariableDeclarations(t *testing.T) {
+	got, err := parse(`contract C {
+	function f() public {
+		uint256 x = 1;
+		bool done;
+		uint[] memory xs = ys;
+		Lib.Point storage pt = points[0];
+		address payable to = payable(msg.sender);
+		mapping(uint => uint) storage m = balances;
+		(uint a, , bool c) = g();
+		(, string memory s) = h();
+		(a, b) = (b, a);
+		(x) = 2;
+		x * y;
+		for (uint i = 0; i < 10; i++) {}
+		address payable[] memory ps;
+		(address payable[2] memory qs, uint n) = g();
+	}
+}`)
+	assert.Require(t, err == nil)
+	body := got.ContractDefinition[0].FunctionDefinitions[0].Body
+	assert.Require(t, len(body.List) == 14)
+
+	x := body.List[0].(*ast.VariableDeclarationStmt)
+	assert.OK(t, !x.Lparen.IsValid() && !x.Rparen.IsValid())
+	assert.Require(t, len(x.Vars) == 1)
+	assert.OK(t, x.Vars[0].Typ.(*ast.ElementaryType).Name == "uint256")
+	assert.OK(t, x.Vars[0].Location == nil)
+	assert.OK(t, x.Vars[0].Name.Name == "x")
+	assert.OK(t, x.Value.(*ast.BasicLit).Value == "1")
+
+	done := body.List[1].(*ast.VariableDeclarationStmt)
+	assert.OK(t, done.Vars[0].Name.Name == "done")
+	assert.OK(t, done.Value == nil)
+
+	xs := body.List[2].(*ast.VariableDeclarationStmt)
+	arr := xs.Vars[0].Typ.(*ast.ArrayType)
+	assert.OK(t, arr.Elt.(*ast.ElementaryType).Name == "uint" && arr.Len == nil)
+	assert.OK(t, xs.Vars[0].Location.Name == "memory")
+
+	pt := body.List[3].(*ast.VariableDeclarationStmt)
+	assert.OK(t, pt.Vars[0].Typ.(*ast.UserDefinedType).Path[1].Name == "Point")
+	assert.OK(t, pt.Vars[0].Location.Name == "storage")
+
+	to := body.List[4].(*ast.VariableDeclarationStmt)
+	assert.OK(t, to.Vars[0].Typ.(*ast.ElementaryType).Payable.IsValid())
+	assert.OK(t, to.Vars[0].Name.Name == "to")
+
+	m := body.List[5].(*ast.VariableDeclarationStmt)
+	assert.OK(t, m.Vars[0].Name.Name == "m")
+
+	tuple := body.List[6].(*ast.VariableDeclarationStmt)
+	assert.OK(t, tuple.Lparen.IsValid() && tuple.Rparen > tuple.Lparen)
+	assert.Require(t, len(tuple.Vars) == 3)
+	assert.OK(t, tuple.Vars[0].Name.Name == "a")
+	assert.OK(t, tuple.Vars[1] == nil)
+	assert.OK(t, tuple.Vars[2].Typ.(*ast.ElementaryType).Name == "bool")
+	assert.OK(t, tuple.Value.(*ast.CallExpr).Fun.(*ast.Ident).Name == "g")
+
+	leading := body.List[7].(*ast.VariableDeclarationStmt)
+	assert.Require(t, len(leading.Vars) == 2)
+	assert.OK(t, leading.Vars[0] == nil)
+	assert.OK(t, leading.Vars[1].Location.Name == "memory")
+
+	swap := body.List[8].(*ast.ExprStmt).X.(*ast.BinaryExpr)
+	assert.OK(t, swap.Op == token.ASSIGN)
+	assert.OK(t, len(swap.X.(*ast.TupleExpr).Elts) == 2)
+	assert.OK(t, swap.Y.(*ast.TupleExpr).Elts[0].(*ast.Ident).Name == "b")
+
+	paren := body.List[9].(*ast.ExprStmt).X.(*ast.BinaryExpr)
+	assert.OK(t, paren.X.(*ast.ParenExpr).X.(*ast.Ident).Name == "x")
+
+	assert.OK(t, body.List[10].(*ast.ExprStmt).X.(*ast.BinaryExpr).Op == token.MUL)
+
+	loop := body.List[11].(*ast.ForStmt)
+	assert.OK(t, loop.Init.(*ast.VariableDeclarationStmt).Vars[0].Name.Name == "i")
+
+	ps := body.List[12].(*ast.VariableDeclarationStmt)
+	psArr := ps.Vars[0].Typ.(*ast.ArrayType)
+	assert.OK(t, psArr.Elt.(*ast.ElementaryType).Payable.IsValid() && psArr.Len == nil)
+	assert.OK(t, ps.Vars[0].Location.Name == "memory")
+	assert.OK(t, ps.Vars[0].Name.Name == "ps")
+
+	qs := body.List[13].(*ast.VariableDeclarationStmt)
+	assert.Require(t, len(qs.Vars) == 2)
+	qsArr := qs.Vars[0].Typ.(*ast.ArrayType)
+	assert.OK(t, qsArr.Elt.(*ast.ElementaryType).Payable.IsValid())
+	assert.OK(t, qsArr.Len.(*ast.BasicLit).Value == "2")
+	assert.OK(t, qs.Vars[0].Name.Name == "qs")
+	assert.OK(t, qs.Vars[1].Name.Name == "n")
+}
+
+func TestParseVariableDeclarations_Errors(t *testing.T) {
+	_, err := parse(`contract C {
+	function f() public {
+		f() x = 1;
+		(uint a, b) = g();
+		(uint c, bool d);
+	}
+}`)
+	errs := err.(scanner.ErrorList)
+	assert.Require(t, len(errs) == 3)
+	assert.OK(t, errs[0].Msg == "expected type name")
+	assert.OK(t, errs[0].Pos.Line == 3)
+	assert.OK(t, errs[1].Msg == "expected variable declaration")
+	assert.OK(t, errs[1].Pos.Line == 4 && errs[1].Pos.Column == len(`		(uint a, b`))
+	assert.OK(t, errs[2].Msg == "expected '=', found ';'")
+	assert.OK(t, errs[2].Pos.Line == 5)
+}
+
+func TestParseTypeNames(t *testing.T) {
+	got, err := parse(`contract C {
+	mapping(address => mapping(uint => bool)) approvals;
+	mapping(address owner => Lib.Info info) public infos;
+	uint256[] values;
+	bytes32[4][] private hashes;
+	address payable wallet;
+	function (uint) external returns (bool) callback;
+	function (uint) internal pure [] internal handlers;
+	ufixed128x18 rate;
+	uint7 notElementary;
+	byte b;
+	function (uint) external returns (bool) public onCall;
+
+	function() external payable {}
+	function f(function (uint) external g) public {
+		function (uint) external h = g;
+		mapping(uint => uint) storage m = counts;
+	}
+	function () public payable {}
+}`)
+	assert.Require(t, err == nil)
+	vars := got.ContractDefinition[0].StateVariableDeclarations
+	assert.Require(t, len(vars) == 11)
+
+	approvals := vars[0].Typ.(*ast.Mapping)
+	assert.OK(t, approvals.Mapping.IsValid() && approvals.Rparen > approvals.Lparen)
+	assert.OK(t, approvals.Key.(*ast.ElementaryType).Name == "address")
+	assert.OK(t, approvals.KeyName == nil)
+	inner := approvals.Value.(*ast.Mapping)
+	assert.OK(t, inner.Key.(*ast.ElementaryType).Name == "uint")
+	assert.OK(t, inner.Value.(*ast.ElementaryType).Name == "bool")
+	assert.OK(t, vars[0].Name.Name == "approvals")
+
+	infos := vars[1].Typ.(*ast.Mapping)
+	assert.OK(t, infos.KeyName.Name == "owner")
+	assert.OK(t, len(infos.Value.(*ast.UserDefinedType).Path) == 2)
+	assert.OK(t, infos.ValueName.Name == "info")
+	assert.OK(t, vars[1].Visibility == "public")
+
+	values := vars[2].Typ.(*ast.ArrayType)
+	assert.OK(t, values.Elt.(*ast.ElementaryType).Name == "uint256")
+	assert.OK(t, values.Len == nil)
+
+	hashes := vars[3].Typ.(*ast.ArrayType)
+	assert.OK(t, hashes.Len == nil)
+	fixed := hashes.Elt.(*ast.ArrayType)
+	assert.OK(t, fixed.Elt.(*ast.ElementaryType).Name == "bytes32")
+	assert.OK(t, fixed.Len.(*ast.BasicLit).Value == "4")
+	assert.OK(t, vars[3].Visibility == "private")
+
+	wallet := vars[4].Typ.(*ast.ElementaryType)
+	assert.OK(t, wallet.Name == "address" && wallet.Payable.IsValid())
+
+	callback := vars[5].Typ.(*ast.FunctionType)
+	assert.OK(t, callback.Function.IsValid())
+	assert.OK(t, len(callback.Params.List) == 1)
+	assert.OK(t, callback.Visibility == "external")
+	assert.OK(t, callback.Returns.List[0].Typ.(*ast.ElementaryType).Name == "bool")
+	assert.OK(t, vars[5].Name.Name == "callback")
+
+	handlers := vars[6].Typ.(*ast.ArrayType).Elt.(*ast.FunctionType)
+	assert.OK(t, handlers.Visibility == "internal" && handlers.Mutability == "pure")
+	assert.OK(t, vars[6].Visibility == "internal")
+
+	assert.OK(t, vars[7].Typ.(*ast.ElementaryType).Name == "ufixed128x18")
+	assert.OK(t, vars[8].Typ.(*ast.UserDefinedType).Path[0].Name == "uint7")
+	assert.OK(t, vars[9].Typ.(*ast.ElementaryType).Name == "byte")
+	onCall := vars[10].Typ.(*ast.FunctionType)
+	assert.OK(t, onCall.Returns != nil)
+	assert.OK(t, vars[10].Visibility == "public")
+	assert.OK(t, vars[10].Name.Name == "onCall")
+
+	fns := got.ContractDefinition[0].FunctionDefinitions
+	assert.Require(t, len(fns) == 3)
+	assert.OK(t, fns[0].Name == nil)
+	assert.OK(t, fns[0].Visibility == "external" && fns[0].Mutability == "payable")
+	assert.OK(t, fns[1].Params.List[0].Typ.(*ast.FunctionType).Visibility == "external")
+	body := fns[1].Body.List
+	assert.OK(t, body[0].(*ast.VariableDeclarationStmt).Vars[0].Typ.(*ast.FunctionType).Params != nil)
+	assert.OK(t, body[1].(*ast.VariableDeclarationStmt).Vars[0].Typ.(*ast.Mapping).Value.(*ast.ElementaryType).Name == "uint")
+	assert.OK(t, fns[2].Name == nil)
+	assert.OK(t, fns[2].Visibility == "public" && fns[2].Mutability == "payable")
+}
+
+func TestParseTypeNames_Errors(t *testing.T) {
+	got, err := parse(`contract C {
+	mapping( => uint) a;
+	mapping(1 => uint) b;
+}`)
+	assert.Require(t, err != nil)
+	list := err.(scanner.ErrorList)
+	assert.Require(t, len(list) == 2)
+	assert.OK(t, list[0].Error() == "2:11: expected key type, found '=>'")
+	assert.OK(t, list[1].Error() == "3:10: expected key type, found '1'")
+	vars := got.ContractDefinition[0].StateVariableDeclarations
+	assert.Require(t, len(vars) == 2)
+	a := vars[0].Typ.(*ast.Mapping).Key.(*ast.BadExpr)
+	assert.OK(t, a.From == a.To)
+	b := vars[1].Typ.(*ast.Mapping)
+	assert.OK(t, b.Key.(*ast.BadExpr).To == b.Key.(*ast.BadExpr).From+1)
+	assert.OK(t, b.Value.(*ast.ElementaryType).Name == "uint")
+	assert.OK(t, vars[1].Name.Name == "b")
+}
+
+func TestIsElementaryType(t *testing.T) {
+	for _, name := range []string{"address", "bool", "string", "bytes", "uint", "int8", "uint256", "bytes1", "bytes32", "fixed", "fixed8x0", "ufixed256x80"} {
+		assert.OK(t, isElementaryType(name), name)
+	}
+	for _, name := range []string{"Token", "uint7", "uint264", "uint08", "int0", "bytes0", "bytes33", "fixed8", "fixed8x81", "ufixedx18"} {
+		assert.OK(t, !isElementaryType(name), name)
+	}
+}
+
+func TestParsePragmas(t *testing.T) {
+	f, got, err := parseFile(`// SPDX-License-Identifier: MIT
+pragma solidity >=0.6.0  <0.9.0;
+pragma abicoder v2;
+pragma experimental ABIEncoderV2;
+contract C {}
+`)
+	assert.Require(t, err == nil)
+	assert.Require(t, len(got.PragmaDirectives) == 3)
+
+	solidity := got.PragmaDirectives[0]
+	assert.OK(t, f.Position(solidity.Pragma).Line == 2 && f.Position(solidity.Pragma).Column == 1)
+	assert.OK(t, f.Position(solidity.Name.NamePos).Column == len(`pragma s`))
+	assert.OK(t, f.Position(solidity.ValuePos).Column == len(`pragma solidity >`))
+	assert.OK(t, solidity.Value == ">=0.6.0  <0.9.0")
+	assert.OK(t, f.Position(solidity.Semicolon).Column == len(`pragma solidity >=0.6.0  <0.9.0;`))
+	assert.Require(t, solidity.Version != nil)
+	assert.Require(t, len(solidity.Version.Sets) == 1)
+	constraints := solidity.Version.Sets[0].Constraints
+	assert.Require(t, len(constraints) == 2)
+	assert.OK(t, constraints[0].Op == token.GEQ)
+	assert.OK(t, constraints[0].Version.Value == "0.6.0")
+	assert.OK(t, constraints[1].Op == token.LSS)
+	assert.OK(t, f.Position(constraints[1].OpPos).Column == len(`pragma solidity >=0.6.0  <`))
+	assert.OK(t, f.Position(constraints[1].Version.ValuePos).Column == len(`pragma solidity >=0.6.0  <0`))
+
+	abicoder := got.PragmaDirectives[1]
+	assert.OK(t, abicoder.Name.Name == "abicoder")
+	assert.OK(t, abicoder.Value == "v2")
+	assert.OK(t, abicoder.Version == nil)
+	assert.OK(t, got.PragmaDirectives[2].Value == "ABIEncoderV2")
+	assert.OK(t, got.Units[2] == got.PragmaDirectives[2])
+}
+
+func TestParsePragmas_VersionRange(t *testing.T) {
+	tests := []struct {
+		version string
+		matches []string
+		rejects []string
+	}{
+		{"0.8.19", []string{"0.8.19"}, []string{"0.8.18", "0.8.20"}},
+		{"=0.8", []string{"0.8.0", "0.8.30"}, []string{"0.7.6", "0.9.0"}},
+		{"^0.8.1", []string{"0.8.1", "0.8.30"}, []string{"0.8.0", "0.9.0"}},
+		{"^1.2.3", []string{"1.2.3", "1.9.0"}, []string{"1.2.2", "2.0.0"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"^0.0", []string{"0.0.0", "0.0.9"}, []string{"0.1.0"}},
+		{"~0.8.1", []string{"0.8.1", "0.8.9"}, []string{"0.8.0", "0.9.0"}},
+		{"~1", []string{"1.0.0", "1.9.9"}, []string{"2.0.0"}},
+		{">=0.6.0 <0.9.0", []string{"0.6.0", "0.8.30"}, []string{"0.5.17", "0.9.0"}},
+		{"> 0.8", []string{"0.9.0"}, []string{"0.8.30"}},
+		{"<=0.8.x", []string{"0.8.30"}, []string{"0.9.0"}},
+		{"0.8.x", []string{"0.8.0", "0.8.30"}, []string{"0.9.0"}},
+		{"*", []string{"0.4.0", "1.0.0"}, nil},
+		{"0.6.0 - 0.7", []string{"0.6.0", "0.7.6"}, []string{"0.5.17", "0.8.0"}},
+		{"^0.5.0 || >=0.7.0 <0.8.0", []string{"0.5.17", "0.7.6"}, []string{"0.6.12", "0.8.0"}},
+		{"0.8.0-nightly.2020.1.1", []string{"0.8.0"}, []string{"0.8.1"}},
+	}
+	for _, tt := range tests {
+		got, err := parse("pragma solidity " + tt.version + ";")
+		assert.Require(t, err == nil, tt.version)
+		r := got.PragmaDirectives[0].Version
+		assert.Require(t, r != nil, tt.version)
+		for _, v := range tt.matches {
+			assert.OK(t, r.Match(version(v)), tt.version, v)
+		}
+		for _, v := range tt.rejects {
+			assert.OK(t, !r.Match(version(v)), tt.version, v)
+		}
+	}
+}
+
+// version splits a version like 0.8.19 into its components.
+func version(s string) (major, minor, patch int) {
+	fmt.Sscanf(s, "%d.%d.%d", &major, &minor, &patch)
+	return
+}
+
+func TestParsePragmas_Errors(t *testing.T) {
+	tests := []struct {
+		version, msg string
+	}{
+		{"", "1:17: expected version"},
+		{"^", "1:18: expected version number"},
+		{">=0.6.0 ||", "1:27: expected version"},
+		{"0.a", "1:19: expected version number"},
+		{"- 0.8.0", "1:17: expected version number"},
+	}
+	for _, tt := range tests {
+		got, err := parse("pragma solidity " + tt.version + ";")
+		assert.Require(t, err != nil, tt.version)
+		assert.OK(t, err.Error() == tt.msg, tt.version)
+		assert.OK(t, got.PragmaDirectives[0].Version == nil, tt.version)
+	}
+}
+
+func TestParseImports(t *testing.T) {
+	f, got, err := parseFile(`import "./a.sol";
+import './b.sol' as B;
+import * as C from "./c.sol";
+import {X as Y, Z} from "../d.sol";
+import {} from "e.sol";
+`)
+	assert.Require(t, err == nil)
+	imps := got.ImportDirectives
+	assert.Require(t, len(imps) == 5)
+
+	assert.OK(t, imps[0].Path == "./a.sol")
+	assert.OK(t, f.Position(imps[0].Import).Column == 1)
+	assert.OK(t, f.Position(imps[0].PathPos).Column == len(`import "`))
+	assert.OK(t, f.Position(imps[0].Semicolon).Column == len(`import "./a.sol";`))
+	assert.OK(t, imps[0].Alias == nil && !imps[0].Star.IsValid() && imps[0].Symbols == nil)
+
+	assert.OK(t, imps[1].Path == "./b.sol")
+	assert.OK(t, imps[1].Alias.Name == "B")
+
+	assert.OK(t, f.Position(imps[2].Star).Column == len(`import *`))
+	assert.OK(t, imps[2].Alias.Name == "C")
+	assert.OK(t, imps[2].Path == "./c.sol")
+
+	assert.Require(t, len(imps[3].Symbols) == 2)
+	assert.OK(t, imps[3].Symbols[0].Name.Name == "X")
+	assert.OK(t, imps[3].Symbols[0].Alias.Name == "Y")
+	assert.OK(t, f.Position(imps[3].Symbols[0].Alias.NamePos).Column == len(`import {X as Y`))
+	assert.OK(t, imps[3].Symbols[1].Name.Name == "Z")
+	assert.OK(t, imps[3].Symbols[1].Alias == nil)
+	assert.OK(t, imps[3].Alias == nil)
+	assert.OK(t, imps[3].Path == "../d.sol")
+
+	assert.OK(t, len(imps[4].Symbols) == 0)
+	assert.OK(t, imps[4].Path == "e.sol")
+}
+
+func TestParseImports_Errors(t *testing.T) {
+	got, err := parse(`import a.sol;
+import * from "b.sol";
+import {X} "c.sol";
+contract C {}
+`)
+	errs := err.(scanner.ErrorList)
+	assert.Require(t, len(errs) == 3)
+	assert.OK(t, errs[0].Error() == "1:8: expected import path, found 'a'")
+	assert.OK(t, errs[1].Error() == "2:10: expected 'as', found 'from'")
+	assert.OK(t, errs[2].Error() == "3:12: expected 'from', found '\"c.sol\"'")
+	assert.OK(t, len(got.ImportDirectives) == 3)
+	assert.OK(t, got.ImportDirectives[2].Path == "c.sol")
+	assert.OK(t, len(got.ContractDefinition) == 1)
+}
+
+func TestParseAssembly(t *testing.T) {
+	f, got, err := parseFile(`contract C {
+	function f() public {
+		assembly {
+			let x :=
+		}
+		uint256 y = 1;
+	}
+	function g() public { assembly { } }
+}`)
+	assert.Require(t, err != nil)
+	errs := err.(scanner.ErrorList)
+	assert.Require(t, len(errs) == 1)
+	assert.OK(t, errs[0].Error() == "5:3: expected expression, found '}'")
+
+	c := got.ContractDefinition[0]
+	body := c.FunctionDefinitions[0].Body
+	assert.Require(t, len(body.List) == 2)
+	assembly := body.List[0].(*ast.AssemblyStmt)
+	assert.OK(t, f.Position(assembly.Body.Rbrace).Line == 5)
+	assert.OK(t, body.List[1].(*ast.VariableDeclarationStmt).Vars[0].Name.Name == "y")
+
+	empty := c.FunctionDefinitions[1].Body.List[0].(*ast.AssemblyStmt)
+	assert.OK(t, len(empty.Body.List) == 0)
+}
+
+func TestParseAssembly_Unfinished(t *testing.T) {
+	_, got, err := parseFile(`contract A { uint x; function g() public { assembly } function f() public { x = 1; } }`)
+	assert.Require(t, err != nil)
+	errs := err.(scanner.ErrorList)
+	assert.OK(t, errs[0].Error() == "1:53: expected '{', found '}'")
+
+	fns := got.ContractDefinition[0].FunctionDefinitions
+	assert.Require(t, len(fns) == 2)
+	assembly := fns[0].Body.List[0].(*ast.AssemblyStmt)
+	assert.Require(t, assembly.Body != nil)
+	assert.OK(t, len(assembly.Body.List) == 0)
+	assert.OK(t, assembly.End() == fns[0].Body.Rbrace+1)
+	assert.OK(t, len(fns[1].Body.List) == 1)
+}
+
+func TestParseNodeRanges(t *testing.T) {
+	src := `pragma solidity ^0.8.0 || >=0.6.0 <0.7.0;
+import {A as B} from "./a.sol";
+
+abstract contract C is B {
+	using L for uint256;
+	type Price is uint128;
+	uint256 public constant π = 3 ether;
+	event E(address indexed from, uint256);
+	error Err(string reason);
+	struct S { uint256 a; }
+	enum K { X, Y }
+	modifier m() virtual;
+	function (uint256) external view f;
+
+	function g(address payable to) public override(B) onlyOwner returns (uint256 r) {
+		uint256 x = a[i++] + -b;
+		(bool ok, ) = to.call{value: 1}("");
+		do { continue; } while (x < 1);
+		if (ok) return x; else revert Err(unicode"é");
+		emit E(to, type(uint8).max);
+		try this.h() {} catch Error(string memory s) {}
+		assembly { let y := x }
+		unchecked { x--; }
+	}
+}`
+	f, got, err := parseFile(src)
+	assert.Require(t, err == nil)
+	runes := []rune(src)
+	text := func(n ast.Node) string {
+		return string(runes[f.Offset(n.Pos()):f.Offset(n.End())])
+	}
+
+	assert.OK(t, got.Pos() == f.Pos(0) && got.End() == f.Pos(len(runes)))
+	pragma := got.PragmaDirectives[0]
+	assert.OK(t, text(pragma) == "pragma solidity ^0.8.0 || >=0.6.0 <0.7.0;")
+	assert.OK(t, text(pragma.Version) == "^0.8.0 || >=0.6.0 <0.7.0")
+	assert.OK(t, text(pragma.Version.Sets[1]) == ">=0.6.0 <0.7.0")
+	imp := got.ImportDirectives[0]
+	assert.OK(t, text(imp) == `import {A as B} from "./a.sol";`)
+	assert.OK(t, text(imp.Symbols[0]) == "A as B")
+
+	c := got.ContractDefinition[0]
+	assert.OK(t, text(c)[:len("abstract contract C")] == "abstract contract C")
+	tests := []string{
+		"using L for uint256;",
+		"type Price is uint128;",
+		"uint256 public constant π = 3 ether;",
+		"event E(address indexed from, uint256);",
+		"error Err(string reason);",
+		"struct S { uint256 a; }",
+		"enum K { X, Y }",
+		"modifier m() virtual;",
+		"function (uint256) external view f;",
+	}
+	for i, expected := range tests {
+		assert.OK(t, text(c.Members[i]) == expected, expected)
+	}
+	assert.OK(t, text(c.Members[2].(*ast.StateVariableDeclaration).Rhs) == "3 ether")
+	assert.OK(t, text(c.Members[3].(*ast.EventDefinition).Params.List[0]) == "address indexed from")
+	assert.OK(t, text(c.Members[8].(*ast.StateVariableDeclaration).Typ) == "function (uint256) external view")
+
+	g := c.FunctionDefinitions[0]
+	assert.OK(t, text(g)[:len("function g(")] == "function g(")
+	assert.OK(t, g.End() == c.Rbrace-1)
+	assert.OK(t, text(g.Params.List[0]) == "address payable to")
+	assert.OK(t, text(g.Override) == "override(B)")
+	assert.OK(t, text(g.Modifiers[0]) == "onlyOwner")
+	assert.OK(t, text(g.Returns) == "(uint256 r)")
+
+	stmts := []string{
+		"uint256 x = a[i++] + -b;",
+		`(bool ok, ) = to.call{value: 1}("");`,
+		"do { continue; } while (x < 1);",
+		`if (ok) return x; else revert Err(unicode"é");`,
+		"emit E(to, type(uint8).max);",
+		"try this.h() {} catch Error(string memory s) {}",
+		"assembly { let y := x }",
+		"unchecked { x--; }",
+	}
+	for i, expected := range stmts {
+		assert.OK(t, text(g.Body.List[i]) == expected, expected)
+	}
+	decl := g.Body.List[0].(*ast.VariableDeclarationStmt)
+	assert.OK(t, text(decl.Vars[0]) == "uint256 x")
+	sum := decl.Value.(*ast.BinaryExpr)
+	assert.OK(t, text(sum.X) == "a[i++]")
+	assert.OK(t, text(sum.X.(*ast.IndexExpr).Index) == "i++")
+	assert.OK(t, text(sum.Y) == "-b")
+	call := g.Body.List[1].(*ast.VariableDeclarationStmt).Value.(*ast.CallExpr)
+	assert.OK(t, text(call.Fun) == "to.call{value: 1}")
+	assert.OK(t, text(call.Fun.(*ast.CallOptions).Options.List[0]) == "value: 1")
+	ifStmt := g.Body.List[3].(*ast.IfStmt)
+	assert.OK(t, text(ifStmt.Else) == `revert Err(unicode"é");`)
+	emit := g.Body.List[4].(*ast.EmitStmt)
+	assert.OK(t, text(emit.Call.(*ast.CallExpr).Args[1]) == "type(uint8).max")
+	try := g.Body.List[5].(*ast.TryStmt)
+	assert.OK(t, text(try.Catches[0]) == "catch Error(string memory s) {}")
+	assembly := g.Body.List[6].(*ast.AssemblyStmt)
+	assert.OK(t, text(assembly.Body.List[0]) == "let y := x")
+}
@@ -0,0 +1,164 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/blockchain-labs-org/solzaemon/ast"
+	"github.com/blockchain-labs-org/solzaemon/token"
+)
+
+// versionParser parses the version constraint of `pragma solidity` from the
+// raw text of the pragma.
+type versionParser struct {
+	p   *Parser
+	pos token.Pos // position of src[0]
+	src []rune
+	i   int
+}
+
+// parseVersionRange parses text at pos as a version range like
+// `>=0.6.0 <0.9.0 || ^0.8.0`. It reports an error and returns nil if text
+// is malformed.
+func (p *Parser) parseVersionRange(pos token.Pos, text string) *ast.VersionRange {
+	v := &versionParser{p: p, pos: pos, src: []rune(text)}
+	r := &ast.VersionRange{}
+	for {
+		set := v.parseSet()
+		if set == nil {
+			return nil
+		}
+		r.Sets = append(r.Sets, set)
+		if !v.consume("||") {
+			return r
+		}
+	}
+}
+
+func (v *versionParser) offset() token.Pos {
+	return v.pos + token.Pos(v.i)
+}
+
+func (v *versionParser) skipSpace() {
+	for v.i < len(v.src) && (v.src[v.i] == ' ' || v.src[v.i] == '\t' || v.src[v.i] == '\n' || v.src[v.i] == '\r') {
+		v.i++
+	}
+}
+
+// consume skips blanks and s if s follows.
+func (v *versionParser) consume(s string) bool {
+	v.skipSpace()
+	if strings.HasPrefix(string(v.src[v.i:]), s) {
+		v.i += len(s)
+		return true
+	}
+	return false
+}
+
+func (v *versionParser) error(msg string) {
+	v.p.error(v.offset(), msg)
+}
+
+func (v *versionParser) parseSet() *ast.VersionSet {
+	set := &ast.VersionSet{}
+	for {
+		v.skipSpace()
+		if v.i == len(v.src) || strings.HasPrefix(string(v.src[v.i:]), "||") {
+			break
+		}
+		c := v.parseConstraint()
+		if c == nil {
+			return nil
+		}
+		if c.Op == token.ILLEGAL {
+			v.skipSpace()
+			if v.i < len(v.src) && v.src[v.i] == '-' {
+				c.OpPos = v.offset()
+				c.Op = token.SUB
+				v.i++
+				v.skipSpace()
+				if c.Upper = v.parseNumber(); c.Upper == nil {
+					return nil
+				}
+			}
+		}
+		set.Constraints = append(set.Constraints, c)
+	}
+	if len(set.Constraints) == 0 {
+		v.error("expected version")
+		return nil
+	}
+	return set
+}
+
+var versionOps = []struct {
+	lit string
+	tok token.Token
+}{
+	// longest first
+	{">=", token.GEQ},
+	{"<=", token.LEQ},
+	{">", token.GTR},
+	{"<", token.LSS},
+	{"=", token.ASSIGN},
+	{"^", token.XOR},
+	{"~", token.TILDE},
+}
+
+func (v *versionParser) parseConstraint() *ast.VersionConstraint {
+	c := &ast.VersionConstraint{}
+	for _, op := range versionOps {
+		if strings.HasPrefix(string(v.src[v.i:]), op.lit) {
+			c.OpPos = v.offset()
+			c.Op = op.tok
+			v.i += len(op.lit)
+			v.skipSpace()
+			break
+		}
+	}
+	if c.Version = v.parseNumber(); c.Version == nil {
+		return nil
+	}
+	return c
+}
+
+// parseNumber parses a version with up to three components, each of which
+// is a number or a wildcard x, X or *.
+func (v *versionParser) parseNumber() *ast.VersionNumber {
+	num := &ast.VersionNumber{ValuePos: v.offset()}
+	start := v.i
+	wildcard := false
+	for level := 0; level < 3; level++ {
+		if level > 0 {
+			if v.i == len(v.src) || v.src[v.i] != '.' {
+				break
+			}
+			v.i++
+		}
+		if v.i < len(v.src) && (v.src[v.i] == 'x' || v.src[v.i] == 'X' || v.src[v.i] == '*') {
+			wildcard = true
+			v.i++
+			continue
+		}
+		from := v.i
+		for v.i < len(v.src) && '0' <= v.src[v.i] && v.src[v.i] <= '9' {
+			v.i++
+		}
+		n, err := strconv.Atoi(string(v.src[from:v.i]))
+		if err != nil {
+			v.error("expected version number")
+			return nil
+		}
+		if !wildcard {
+			num.Numbers = append(num.Numbers, n)
+		}
+	}
+	if v.i < len(v.src) && (v.src[v.i] == '-' || v.src[v.i] == '+') {
+		// NOTE: pre-release and build metadata are ignored
+		for v.i < len(v.src) && v.src[v.i] != ' ' && v.src[v.i] != '\t' && v.src[v.i] != '\n' && v.src[v.i] != '|' {
+			v.i++
+		}
+	}
+	num.Value = string(v.src[start:v.i])
+	return num
+}
@@ -0,0 +1,981 @@
+package printer
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/blockchain-labs-org/solzaemon/ast"
+	"github.com/blockchain-labs-org/solzaemon/token"
+	"github.com/blockchain-labs-org/solzaemon/yul"
+)
+
+// ----------------------------------------------------------------------------
+// Directives and declarations
+
+func (p *printer) program(prog *ast.Program) {
+	for i, unit := range prog.Units {
+		min, max := 0, 0
+		if i > 0 {
+			min, max = unitSpacing(prog.Units[i-1], unit)
+		}
+		p.linebreak(unit.Pos(), min, max)
+		p.decl(unit)
+		p.last = unit.End()
+	}
+}
+
+// unitSpacing returns the minimum and maximum number of blank lines
+// between two top-level units: two between declarations, one between
+// directives and declarations.
+func unitSpacing(prev, next ast.Node) (min, max int) {
+	switch {
+	case isDirective(prev) && isDirective(next):
+		return 0, 1
+	case isDirective(prev) || isDirective(next):
+		return 1, 1
+	}
+	return 2, 2
+}
+
+// memberSpacing returns the minimum and maximum number of blank lines
+// between two contract members: one around members spanning several
+// lines.
+func memberSpacing(prev, next ast.Node) (min, max int) {
+	if isMultiline(prev) || isMultiline(next) {
+		return 1, 1
+	}
+	return 0, 1
+}
+
+func isDirective(n ast.Node) bool {
+	switch n.(type) {
+	case *ast.PragmaDirective, *ast.ImportDirective:
+		return true
+	}
+	return false
+}
+
+// isMultiline reports whether the declaration n is printed on several
+// lines.
+func isMultiline(n ast.Node) bool {
+	switch n := n.(type) {
+	case *ast.ContractPart, *ast.StructDefinition, *ast.EnumDefinition:
+		return true
+	case *ast.FunctionDefinition:
+		return n.Body != nil
+	case *ast.ModifierDefinition:
+		return n.Body != nil
+	}
+	return false
+}
+
+func (p *printer) decl(n ast.Node) {
+	switch n := n.(type) {
+	case *ast.PragmaDirective:
+		p.print("pragma ", n.Name.Name)
+		if value := strings.Join(strings.Fields(n.Value), " "); value != "" {
+			p.print(" ", value)
+		}
+		p.print(";")
+
+	case *ast.ImportDirective:
+		p.print("import ")
+		switch {
+		case n.Star.IsValid():
+			p.print("* as ", n.Alias.Name, " from ", quote(n.Path))
+		case len(n.Symbols) > 0:
+			p.print("{")
+			for i, sym := range n.Symbols {
+				if i > 0 {
+					p.print(", ")
+				}
+				p.print(sym.Name.Name)
+				if sym.Alias != nil {
+					p.print(" as ", sym.Alias.Name)
+				}
+			}
+			p.print("} from ", quote(n.Path))
+		default:
+			p.print(quote(n.Path))
+			if n.Alias != nil {
+				p.print(" as ", n.Alias.Name)
+			}
+		}
+		p.print(";")
+
+	case *ast.ContractPart:
+		p.contract(n)
+
+	case *ast.StateVariableDeclaration:
+		p.expr(n.Typ)
+		if n.Visibility != "" {
+			p.print(" ", n.Visibility)
+		}
+		if n.IsConstant {
+			p.print(" constant")
+		}
+		if n.Immutable {
+			p.print(" immutable")
+		}
+		p.print(" ")
+		p.expr(n.Name)
+		if n.Rhs != nil {
+			p.token(p.next(n.Name.End()), " = ")
+			p.expr(n.Rhs)
+		}
+		p.token(n.Semicolon, ";")
+
+	case *ast.FunctionDefinition:
+		p.function(n)
+
+	case *ast.ModifierDefinition:
+		var specs []func(p *printer)
+		if n.Virtual {
+			specs = append(specs, keyword("virtual"))
+		}
+		if n.Override != nil {
+			specs = append(specs, func(p *printer) { p.override(n.Override) })
+		}
+		p.signature(func(p *printer) {
+			p.print("modifier ")
+			p.expr(n.Name)
+		}, n.Params, specs, n.Body, n.Semicolon)
+
+	case *ast.UserDefinedValueTypeDefinition:
+		p.print("type ")
+		p.expr(n.Name)
+		p.print(" is ")
+		p.expr(n.Underlying)
+		p.token(n.Semicolon, ";")
+
+	case *ast.EventDefinition:
+		p.print("event ")
+		p.expr(n.Name)
+		p.params(n.Params)
+		if n.Anonymous {
+			p.print(" anonymous")
+		}
+		p.token(n.Semicolon, ";")
+
+	case *ast.ErrorDefinition:
+		p.print("error ")
+		p.expr(n.Name)
+		p.params(n.Params)
+		p.token(n.Semicolon, ";")
+
+	case *ast.StructDefinition:
+		p.print("struct ")
+		p.expr(n.Name)
+		p.print(" ")
+		fields := make([]ast.Node, len(n.Fields))
+		for i, field := range n.Fields {
+			fields[i] = field
+		}
+		p.block(n.Lbrace, fields, n.Rbrace, stmtSpacing, func(p *printer, n ast.Node) {
+			p.expr(n)
+			p.print(";")
+		})
+
+	case *ast.EnumDefinition:
+		p.print("enum ")
+		p.expr(n.Name)
+		p.print(" ")
+		if len(n.Values) == 0 {
+			p.block(n.Lbrace, nil, n.Rbrace, nil, nil)
+			break
+		}
+		values := make([]ast.Node, len(n.Values))
+		for i, value := range n.Values {
+			values[i] = value
+		}
+		p.brokenList(n.Lbrace, "{", values, "}", n.Rbrace)
+
+	case *ast.UsingDirective:
+		p.print("using ")
+		if n.Library != nil {
+			p.expr(n.Library)
+		} else {
+			p.flatList("{", exprList(n.Functions), "}")
+		}
+		p.print(" for ")
+		if n.Typ != nil {
+			p.expr(n.Typ)
+		} else {
+			p.print("*")
+		}
+		if n.Global {
+			p.print(" global")
+		}
+		p.token(n.Semicolon, ";")
+
+	default:
+		p.print(p.source(n.Pos(), n.End()))
+	}
+}
+
+// quote returns path as a string literal.
+func quote(path string) string {
+	if strings.ContainsAny(path, "\"\\\n") {
+		return strconv.Quote(path)
+	}
+	return `"` + path + `"`
+}
+
+// doubleQuote returns lit with double quotes if it is a string literal in
+// single quotes whose contents do not contain a double quote.
+func doubleQuote(lit string) string {
+	i := strings.IndexByte(lit, '\'')
+	if i < 0 || strings.ContainsRune(lit[:i], '"') || len(lit) < i+2 || !strings.HasSuffix(lit, "'") {
+		return lit
+	}
+	contents := lit[i+1 : len(lit)-1]
+	if strings.ContainsRune(contents, '"') {
+		return lit
+	}
+	return lit[:i] + `"` + contents + `"`
+}
+
+// keyword returns a function printing text.
+func keyword(text string) func(p *printer) {
+	return func(p *printer) { p.print(text) }
+}
+
+// contract prints a contract, interface or library. Its bases are put on
+// lines of their own if they do not fit on one line.
+func (p *printer) contract(n *ast.ContractPart) {
+	if n.Abstract {
+		p.print("abstract ")
+	}
+	p.print(strings.ToLower(n.Kind.String()), " ")
+	p.expr(n.Name)
+	bases := make([]ast.Node, len(n.Bases))
+	for i, base := range n.Bases {
+		bases[i] = base
+	}
+	if len(bases) > 0 {
+		if p.fits(func(p *printer) { p.flatList(" is ", bases, " {") }) {
+			p.flatList(" is ", bases, " ")
+		} else {
+			p.print(" is")
+			p.indent++
+			for i, base := range bases {
+				p.linebreak(base.Pos(), 0, 0)
+				p.expr(base)
+				if i < len(bases)-1 {
+					p.print(",")
+				}
+				p.last = base.End()
+			}
+			p.indent--
+			p.newline()
+		}
+	} else {
+		p.print(" ")
+	}
+	members := make([]ast.Node, len(n.Members))
+	for i, member := range n.Members {
+		members[i] = member
+	}
+	p.block(n.Lbrace, members, n.Rbrace, memberSpacing, (*printer).decl)
+}
+
+// function prints a function definition with its specifiers in the order
+// of the style guide: visibility, mutability, virtual, override and
+// modifiers.
+func (p *printer) function(n *ast.FunctionDefinition) {
+	head := keyword("function")
+	switch {
+	case n.Name == nil:
+	case n.Name.NamePos == n.Function:
+		// constructor, fallback or receive
+		head = func(p *printer) { p.expr(n.Name) }
+	default:
+		head = func(p *printer) {
+			p.print("function ")
+			p.expr(n.Name)
+		}
+	}
+
+	var specs []func(p *printer)
+	if n.VisibilityPos.IsValid() {
+		specs = append(specs, func(p *printer) { p.token(n.VisibilityPos, n.Visibility) })
+	}
+	if n.Mutability != "" {
+		specs = append(specs, func(p *printer) { p.token(n.MutabilityPos, n.Mutability) })
+	}
+	if n.Virtual {
+		specs = append(specs, keyword("virtual"))
+	}
+	if n.Override != nil {
+		specs = append(specs, func(p *printer) { p.override(n.Override) })
+	}
+	for _, m := range n.Modifiers {
+		m := m
+		specs = append(specs, func(p *printer) { p.expr(m) })
+	}
+	if n.Returns != nil {
+		specs = append(specs, func(p *printer) {
+			// returns has no position; keep the comments before it there
+			p.intersperse(n.Returns.Lparen)
+			p.print("returns ")
+			p.params(n.Returns)
+		})
+	}
+	p.signature(head, n.Params, specs, n.Body, n.Semicolon)
+}
+
+// signature prints the header of a function or modifier definition, which
+// is head, the parameters and the specifiers, followed by the body or ';'.
+// If the header does not fit on one line, the specifiers are put on lines
+// of their own, and if the parameters do not fit either, so are they.
+func (p *printer) signature(head func(p *printer), params *ast.ParameterList, specs []func(p *printer), body *ast.BlockStmt, semicolon token.Pos) {
+	name := func(p *printer) {
+		head(p)
+		if params != nil {
+			p.params(params)
+		}
+	}
+	comments := params != nil && p.hasComments(params.Lparen, params.Rparen)
+	header := func(p *printer) {
+		name(p)
+		for _, spec := range specs {
+			p.print(" ")
+			spec(p)
+		}
+		if body != nil {
+			p.print(" {")
+		} else {
+			p.print(";")
+		}
+	}
+
+	switch {
+	case !comments && p.fits(header):
+		name(p)
+		for _, spec := range specs {
+			p.print(" ")
+			spec(p)
+		}
+		if body != nil {
+			p.print(" ")
+		}
+	case params == nil || !comments && p.fits(name):
+		name(p)
+		p.specs(specs, body)
+	default:
+		head(p)
+		p.brokenList(params.Lparen, "(", paramList(params.List), ")", params.Rparen)
+		p.specs(specs, body)
+	}
+	if body == nil {
+		p.token(semicolon, ";")
+		return
+	}
+	p.stmt(body)
+}
+
+// specs prints the specifiers of a definition on lines of their own, and
+// starts a line for the body.
+func (p *printer) specs(specs []func(p *printer), body *ast.BlockStmt) {
+	if len(specs) == 0 {
+		if body != nil {
+			p.print(" ")
+		}
+		return
+	}
+	p.indent++
+	for _, spec := range specs {
+		p.newline()
+		spec(p)
+	}
+	p.indent--
+	if body != nil {
+		p.newline()
+	}
+}
+
+func (p *printer) override(n *ast.OverrideSpecifier) {
+	p.token(n.Override, "override")
+	if n.Lparen.IsValid() {
+		p.intersperse(n.Lparen)
+		p.flatList("(", exprList(n.Overrides), ")")
+	}
+}
+
+func (p *printer) params(n *ast.ParameterList) {
+	p.list(n.Lparen, "(", paramList(n.List), ")", n.Rparen)
+}
+
+func paramList(list []*ast.Parameter) []ast.Node {
+	nodes := make([]ast.Node, len(list))
+	for i, x := range list {
+		nodes[i] = x
+	}
+	return nodes
+}
+
+func exprList(list []ast.Expr) []ast.Node {
+	nodes := make([]ast.Node, len(list))
+	for i, x := range list {
+		nodes[i] = x
+	}
+	return nodes
+}
+
+// ----------------------------------------------------------------------------
+// Statements
+
+func (p *printer) stmt(n ast.Node) {
+	switch n := n.(type) {
+	case *ast.EmptyStmt:
+		p.token(n.Semicolon, ";")
+
+	case *ast.ExprStmt:
+		p.expr(n.X)
+		p.token(n.Semicolon, ";")
+
+	case *ast.VariableDeclarationStmt:
+		end := n.Rparen + 1
+		if n.Lparen.IsValid() {
+			p.token(n.Lparen, "(")
+			for i, v := range n.Vars {
+				p.tupleSep(i, len(n.Vars), v == nil)
+				if v != nil {
+					p.expr(v)
+				}
+			}
+			p.token(n.Rparen, ")")
+		} else {
+			p.expr(n.Vars[0])
+			end = n.Vars[0].End()
+		}
+		if n.Value != nil {
+			p.token(p.next(end), " = ")
+			p.expr(n.Value)
+		}
+		p.token(n.Semicolon, ";")
+
+	case *ast.BlockStmt:
+		list := make([]ast.Node, len(n.List))
+		for i, s := range n.List {
+			list[i] = s
+		}
+		p.block(n.Lbrace, list, n.Rbrace, stmtSpacing, (*printer).stmt)
+
+	case *ast.UncheckedStmt:
+		p.print("unchecked ")
+		p.stmt(n.Body)
+
+	case *ast.IfStmt:
+		p.print("if (")
+		p.expr(n.Cond)
+		p.print(")")
+		p.body(n.Body)
+		if n.Else == nil {
+			break
+		}
+		if _, ok := n.Body.(*ast.BlockStmt); ok && !p.hasComments(n.Body.End(), n.ElsePos) {
+			p.print(" ")
+		} else {
+			p.linebreak(n.ElsePos, 0, 0)
+		}
+		p.token(n.ElsePos, "else")
+		p.last = n.ElsePos + token.Pos(len("else"))
+		if _, ok := n.Else.(*ast.IfStmt); ok {
+			p.print(" ")
+			p.intersperse(n.Else.Pos())
+			p.stmt(n.Else)
+		} else {
+			p.body(n.Else)
+		}
+
+	case *ast.ForStmt:
+		p.print("for (")
+		if n.Init != nil {
+			p.stmt(n.Init)
+		} else {
+			p.print(";")
+		}
+		if n.Cond != nil {
+			p.print(" ")
+			p.expr(n.Cond)
+		}
+		p.print(";")
+		if n.Post != nil {
+			p.print(" ")
+			p.expr(n.Post)
+		}
+		p.print(")")
+		p.body(n.Body)
+
+	case *ast.WhileStmt:
+		p.print("while (")
+		p.expr(n.Cond)
+		p.print(")")
+		p.body(n.Body)
+
+	case *ast.DoWhileStmt:
+		p.print("do")
+		p.body(n.Body)
+		if _, ok := n.Body.(*ast.BlockStmt); ok {
+			p.print(" ")
+		} else {
+			p.linebreak(n.While, 0, 0)
+		}
+		p.token(n.While, "while (")
+		p.expr(n.Cond)
+		p.print(")")
+		p.token(n.Semicolon, ";")
+
+	case *ast.BranchStmt:
+		p.print(strings.ToLower(n.Tok.String()))
+		p.token(n.Semicolon, ";")
+
+	case *ast.ReturnStmt:
+		p.print("return")
+		if n.Result != nil {
+			p.print(" ")
+			p.expr(n.Result)
+		}
+		p.token(n.Semicolon, ";")
+
+	case *ast.EmitStmt:
+		p.print("emit ")
+		p.expr(n.Call)
+		p.token(n.Semicolon, ";")
+
+	case *ast.RevertStmt:
+		p.print("revert ")
+		p.expr(n.Call)
+		p.token(n.Semicolon, ";")
+
+	case *ast.TryStmt:
+		p.print("try ")
+		p.expr(n.Call)
+		if n.Returns != nil {
+			p.print(" ")
+			p.intersperse(n.Returns.Lparen)
+			p.print("returns ")
+			p.params(n.Returns)
+		}
+		p.print(" ")
+		p.stmt(n.Body)
+		for _, c := range n.Catches {
+			p.print(" ")
+			p.token(c.Catch, "catch ")
+			if c.Name != nil {
+				p.expr(c.Name)
+			}
+			if c.Params != nil {
+				p.params(c.Params)
+				p.print(" ")
+			} else if c.Name != nil {
+				p.print(" ")
+			}
+			p.stmt(c.Body)
+		}
+
+	case *ast.AssemblyStmt:
+		p.print("assembly ")
+		if n.Dialect != nil {
+			p.print(doubleQuote(n.Dialect.Value), " ")
+		}
+		if len(n.Flags) > 0 {
+			flags := make([]ast.Node, len(n.Flags))
+			for i, flag := range n.Flags {
+				flags[i] = flag
+			}
+			p.flatList("(", flags, ") ")
+		}
+		p.yulStmt(n.Body)
+
+	default:
+		p.print(p.source(n.Pos(), n.End()))
+	}
+}
+
+// body prints the body of a control structure: a block on the same line,
+// and any other statement indented on a line of its own.
+func (p *printer) body(n ast.Stmt) {
+	if _, ok := n.(*ast.BlockStmt); ok {
+		p.print(" ")
+		p.stmt(n)
+		return
+	}
+	p.indent++
+	p.linebreak(n.Pos(), 0, 0)
+	p.stmt(n)
+	p.last = n.End()
+	p.indent--
+}
+
+// tupleSep prints the separator before the i-th of n components of a
+// tuple; omitted components print as nothing, as in `(a, , c)` and `(a,)`.
+func (p *printer) tupleSep(i, n int, omitted bool) {
+	switch {
+	case i == 0:
+	case i == n-1 && omitted:
+		p.print(",")
+	default:
+		p.print(", ")
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Expressions and type names
+
+// binaryOps holds the source text of binary operators, including
+// assignments.
+var binaryOps = map[token.Token]string{
+	token.ADD: "+", token.SUB: "-", token.MUL: "*", token.POW: "**", token.QUO: "/", token.REM: "%",
+	token.AND: "&", token.OR: "|", token.XOR: "^", token.SHL: "<<", token.SHR: ">>", token.SAR: ">>>",
+	token.LAND: "&&", token.LOR: "||",
+	token.EQ: "==", token.NEQ: "!=", token.LSS: "<", token.GTR: ">", token.LEQ: "<=", token.GEQ: ">=",
+	token.ASSIGN: "=", token.ADD_ASSIGN: "+=", token.SUB_ASSIGN: "-=", token.MUL_ASSIGN: "*=",
+	token.QUO_ASSIGN: "/=", token.REM_ASSIGN: "%=", token.AND_ASSIGN: "&=", token.OR_ASSIGN: "|=",
+	token.XOR_ASSIGN: "^=", token.SHL_ASSIGN: "<<=", token.SHR_ASSIGN: ">>=", token.SAR_ASSIGN: ">>>=",
+}
+
+// unaryOps holds the source text of unary operators.
+var unaryOps = map[token.Token]string{
+	token.SUB: "-", token.NOT: "!", token.TILDE: "~", token.INC: "++", token.DEC: "--", token.DELETE: "delete ",
+}
+
+// expr prints an expression, a type name or another node printed inline,
+// like a parameter.
+func (p *printer) expr(n ast.Node) {
+	p.intersperse(n.Pos())
+	switch n := n.(type) {
+	case *ast.Ident:
+		p.print(n.Name)
+
+	case *ast.BasicLit:
+		p.print(doubleQuote(n.Value))
+		if n.Unit != nil {
+			p.print(" ")
+			p.expr(n.Unit)
+		}
+
+	case *ast.BinaryExpr:
+		p.expr(n.X)
+		p.token(n.OpPos, " "+binaryOps[n.Op]+" ")
+		p.expr(n.Y)
+
+	case *ast.UnaryExpr:
+		if n.Postfix {
+			p.expr(n.X)
+			p.token(n.OpPos, unaryOps[n.Op])
+			break
+		}
+		p.print(unaryOps[n.Op])
+		if x, ok := n.X.(*ast.UnaryExpr); ok && !x.Postfix && (n.Op == token.SUB || n.Op == token.DEC) && (x.Op == token.SUB || x.Op == token.DEC) {
+			// not to be scanned as -- or ---
+			p.print(" ")
+		}
+		p.expr(n.X)
+
+	case *ast.ConditionalExpr:
+		p.expr(n.Cond)
+		p.token(n.Question, " ? ")
+		p.expr(n.Then)
+		p.token(n.Colon, " : ")
+		p.expr(n.Else)
+
+	case *ast.TupleExpr:
+		p.print("(")
+		for i, x := range n.Elts {
+			p.tupleSep(i, len(n.Elts), x == nil)
+			if x != nil {
+				p.expr(x)
+			}
+		}
+		p.token(n.Rparen, ")")
+
+	case *ast.ParenExpr:
+		p.print("(")
+		p.expr(n.X)
+		p.token(n.Rparen, ")")
+
+	case *ast.IndexExpr:
+		p.expr(n.X)
+		p.token(n.Lbrack, "[")
+		if n.Index != nil {
+			p.expr(n.Index)
+		}
+		p.token(n.Rbrack, "]")
+
+	case *ast.IndexRangeExpr:
+		p.expr(n.X)
+		p.token(n.Lbrack, "[")
+		if n.Low != nil {
+			p.expr(n.Low)
+		}
+		p.token(n.Colon, ":")
+		if n.High != nil {
+			p.expr(n.High)
+		}
+		p.token(n.Rbrack, "]")
+
+	case *ast.SelectorExpr:
+		p.expr(n.X)
+		p.print(".")
+		p.expr(n.Sel)
+
+	case *ast.CallExpr:
+		p.expr(n.Fun)
+		switch {
+		case !n.Rparen.IsValid():
+			// modifier invocation without arguments
+		case n.NamedArgs != nil:
+			p.print("(")
+			p.expr(n.NamedArgs)
+			p.print(")")
+		default:
+			p.list(n.Lparen, "(", exprList(n.Args), ")", n.Rparen)
+		}
+
+	case *ast.NamedArgList:
+		list := make([]ast.Node, len(n.List))
+		for i, arg := range n.List {
+			list[i] = arg
+		}
+		p.list(n.Lbrace, "{", list, "}", n.Rbrace)
+
+	case *ast.NamedArg:
+		p.expr(n.Name)
+		p.token(n.Colon, ": ")
+		p.expr(n.Value)
+
+	case *ast.CallOptions:
+		p.expr(n.X)
+		p.expr(n.Options)
+
+	case *ast.NewExpr:
+		p.print("new ")
+		p.expr(n.Typ)
+
+	case *ast.MetaTypeExpr:
+		p.print("type(")
+		p.expr(n.Typ)
+		p.print(")")
+
+	case *ast.ArrayLit:
+		p.list(n.Lbrack, "[", exprList(n.Elts), "]", n.Rbrack)
+
+	case *ast.ElementaryType:
+		p.print(n.Name)
+		if n.Payable.IsValid() {
+			p.print(" payable")
+		}
+
+	case *ast.UserDefinedType:
+		for i, name := range n.Path {
+			if i > 0 {
+				p.print(".")
+			}
+			p.print(name.Name)
+		}
+
+	case *ast.Mapping:
+		p.print("mapping(")
+		p.expr(n.Key)
+		if n.KeyName != nil {
+			p.print(" ")
+			p.expr(n.KeyName)
+		}
+		p.print(" => ")
+		p.expr(n.Value)
+		if n.ValueName != nil {
+			p.print(" ")
+			p.expr(n.ValueName)
+		}
+		p.token(n.Rparen, ")")
+
+	case *ast.ArrayType:
+		p.expr(n.Elt)
+		p.token(n.Lbrack, "[")
+		if n.Len != nil {
+			p.expr(n.Len)
+		}
+		p.token(n.Rbrack, "]")
+
+	case *ast.FunctionType:
+		p.print("function")
+		p.params(n.Params)
+		if n.Visibility != "" {
+			p.print(" ")
+			p.token(n.VisibilityPos, n.Visibility)
+		}
+		if n.Mutability != "" {
+			p.print(" ")
+			p.token(n.MutabilityPos, n.Mutability)
+		}
+		if n.Returns != nil {
+			p.print(" ")
+			p.intersperse(n.Returns.Lparen)
+			p.print("returns ")
+			p.params(n.Returns)
+		}
+
+	case *ast.Parameter:
+		p.expr(n.Typ)
+		if n.Indexed.IsValid() {
+			p.print(" indexed")
+		}
+		if n.Location != nil {
+			p.print(" ")
+			p.expr(n.Location)
+		}
+		if n.Name != nil {
+			p.print(" ")
+			p.expr(n.Name)
+		}
+
+	case *ast.VariableDeclaration:
+		p.expr(n.Typ)
+		if n.Location != nil {
+			p.print(" ")
+			p.expr(n.Location)
+		}
+		p.print(" ")
+		p.expr(n.Name)
+
+	default:
+		p.print(p.source(n.Pos(), n.End()))
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Yul
+
+func (p *printer) yulStmt(n ast.Node) {
+	switch n := n.(type) {
+	case *yul.Block:
+		list := make([]ast.Node, len(n.List))
+		for i, s := range n.List {
+			list[i] = s
+		}
+		p.block(n.Lbrace, list, n.Rbrace, stmtSpacing, (*printer).yulStmt)
+
+	case *yul.VariableDeclaration:
+		p.print("let ")
+		p.yulIdents(n.Names)
+		if n.Value != nil {
+			p.print(" := ")
+			p.yulExpr(n.Value)
+		}
+
+	case *yul.Assignment:
+		for i, x := range n.Lhs {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.yulExpr(x)
+		}
+		p.print(" := ")
+		p.yulExpr(n.Value)
+
+	case *yul.ExprStmt:
+		p.yulExpr(n.X)
+
+	case *yul.If:
+		p.print("if ")
+		p.yulExpr(n.Cond)
+		p.print(" ")
+		p.yulStmt(n.Body)
+
+	case *yul.Switch:
+		p.print("switch ")
+		p.yulExpr(n.X)
+		for _, c := range n.Cases {
+			p.linebreak(c.Pos(), 0, 0)
+			if c.Value != nil {
+				p.print("case ")
+				p.yulExpr(c.Value)
+			} else {
+				p.print("default")
+			}
+			p.print(" ")
+			p.yulStmt(c.Body)
+		}
+
+	case *yul.For:
+		p.print("for ")
+		p.yulForBlock(n.Init)
+		p.print(" ")
+		p.yulExpr(n.Cond)
+		p.print(" ")
+		p.yulForBlock(n.Post)
+		p.print(" ")
+		p.yulStmt(n.Body)
+
+	case *yul.BranchStmt:
+		p.print(n.Tok.String())
+
+	case *yul.FunctionDefinition:
+		p.print("function ", n.Name.Name, "(")
+		p.yulIdents(n.Params)
+		p.print(")")
+		if len(n.Returns) > 0 {
+			p.print(" -> ")
+			p.yulIdents(n.Returns)
+		}
+		p.print(" ")
+		p.yulStmt(n.Body)
+
+	default:
+		p.print(p.source(n.Pos(), n.End()))
+	}
+}
+
+// yulForBlock prints the initialization or post-iteration block of a for
+// loop, on one line if it is a single simple statement.
+func (p *printer) yulForBlock(n *yul.Block) {
+	if len(n.List) == 1 && !p.hasComments(n.Lbrace, n.Rbrace) {
+		switch s := n.List[0].(type) {
+		case *yul.VariableDeclaration, *yul.Assignment, *yul.ExprStmt:
+			p.print("{ ")
+			p.yulStmt(s)
+			p.print(" }")
+			p.last = n.End()
+			return
+		}
+	}
+	p.yulStmt(n)
+}
+
+func (p *printer) yulIdents(list []*yul.Ident) {
+	for i, x := range list {
+		if i > 0 {
+			p.print(", ")
+		}
+		p.print(x.Name)
+	}
+}
+
+func (p *printer) yulExpr(n ast.Node) {
+	p.intersperse(n.Pos())
+	switch n := n.(type) {
+	case *yul.Ident:
+		p.print(n.Name)
+
+	case *yul.Literal:
+		p.print(doubleQuote(n.Value))
+
+	case *yul.MemberAccess:
+		p.print(n.X.Name, ".", n.Member.Name)
+
+	case *yul.FunctionCall:
+		p.print(n.Fun.Name, "(")
+		for i, x := range n.Args {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.yulExpr(x)
+		}
+		p.print(")")
+
+	default:
+		p.print(p.source(n.Pos(), n.End()))
+	}
+}
@@ -0,0 +1,459 @@
+// Package printer implements printing of Solidity programs in the
+// canonical style of the Solidity style guide.
+package printer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/blockchain-labs-org/solzaemon/ast"
+	"github.com/blockchain-labs-org/solzaemon/parser"
+	"github.com/blockchain-labs-org/solzaemon/scanner"
+	"github.com/blockchain-labs-org/solzaemon/token"
+)
+
+const (
+	indentWidth = 4  // spaces per indentation level
+	lineWidth   = 79 // maximum line length recommended by the style guide
+)
+
+type comment struct {
+	pos, end token.Pos
+	text     string
+}
+
+type printer struct {
+	file     *token.File
+	src      []rune
+	comments []comment // comments not printed yet, in source order
+	flat     bool      // print on a single line without comments, to measure the width
+
+	buf    bytes.Buffer
+	indent int
+	col    int       // runes on the current output line
+	last   token.Pos // end of the last printed source text
+	space  bool      // a space is due before the next text, after a comment
+	cont   bool      // the current line continues the previous one, after a line comment
+}
+
+// Source formats src, the source code of a Solidity file, in canonical
+// style. It returns an error if src has syntax errors.
+func Source(src []byte) ([]byte, error) {
+	runes := []rune(string(src))
+	f := token.NewFileSet().AddFile("", -1, len(runes))
+	prog, err := parser.Parse(f, runes)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := Fprint(&buf, f, runes, prog); err != nil {
+		return nil, err
+	}
+	// The parser drops some syntax it does not support yet. Make sure
+	// that nothing is lost, as the result may overwrite src.
+	if !sameTokens(runes, []rune(buf.String())) {
+		return nil, errors.New("formatting would change the program")
+	}
+	return buf.Bytes(), nil
+}
+
+// Fprint pretty-prints prog to w. f and src are the file and the source
+// code prog was parsed from; the comments of src are printed along with
+// prog.
+func Fprint(w io.Writer, f *token.File, src []rune, prog *ast.Program) error {
+	p := &printer{file: f, src: src, comments: scanComments(f, src), last: f.Pos(0)}
+	p.program(prog)
+	p.flush(f.Pos(len(src)), 0, 1)
+	_, err := w.Write(p.buf.Bytes())
+	return err
+}
+
+// scanComments returns the comments of src, the source code of f.
+func scanComments(f *token.File, src []rune) []comment {
+	var comments []comment
+	// scan a copy of f, whose lines may be in use
+	s := scanner.NewScanner(token.NewFileSet().AddFile(f.Name(), f.Base(), len(src)), src, nil, scanner.ScanComments)
+	for {
+		pos, tok, lit := s.Scan()
+		switch tok {
+		case token.EOF:
+			return comments
+		case token.COMMENT, token.NATSPEC:
+			end := pos + token.Pos(utf8.RuneCountInString(lit))
+			comments = append(comments, comment{pos: pos, end: end, text: lit})
+		}
+	}
+}
+
+// sameTokens reports whether a and b consist of the same tokens, comments
+// included, in the same order. It allows for the changes of the printer:
+// specifiers may be in another order, string literals may be in double
+// quotes instead of single ones, and the lines of comments may be indented
+// differently.
+func sameTokens(a, b []rune) bool {
+	x, xspecs := tokens(a)
+	y, yspecs := tokens(b)
+	return equal(x, y) && equal(xspecs, yspecs)
+}
+
+// tokens returns the tokens of src as compared by sameTokens: the
+// specifiers, sorted, separately from the others. An override specifier
+// includes its list of bases.
+func tokens(src []rune) (list, specs []string) {
+	f := token.NewFileSet().AddFile("", -1, len(src))
+	s := scanner.NewScanner(f, src, nil, scanner.ScanComments)
+	override := false // scanning the bases of an override specifier
+	for {
+		_, tok, lit := s.Scan()
+		if override {
+			specs[len(specs)-1] += " " + lit
+			override = tok != token.RPAREN && tok != token.EOF
+			continue
+		}
+		switch tok {
+		case token.EOF:
+			sort.Strings(specs)
+			return list, specs
+		case token.COMMENT, token.NATSPEC:
+			lines := strings.Split(lit, "\n")
+			for i, line := range lines {
+				lines[i] = strings.TrimSpace(line)
+			}
+			lit = strings.Join(lines, "\n")
+		case token.STRING, token.HEX_STRING, token.UNICODE_STRING:
+			lit = doubleQuote(lit)
+		case token.PUBLIC, token.PRIVATE, token.INTERNAL, token.EXTERNAL,
+			token.PURE, token.VIEW, token.PAYABLE, token.CONSTANT, token.IMMUTABLE, token.VIRTUAL:
+			specs = append(specs, lit)
+			continue
+		case token.OVERRIDE:
+			specs = append(specs, lit)
+			override = s.Peek() == token.LPAREN
+			continue
+		}
+		list = append(list, tok.String()+" "+lit)
+	}
+}
+
+func equal(x, y []string) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ----------------------------------------------------------------------------
+// Output
+
+// print prints the texts, which must not contain newlines, indenting them
+// at the start of a line.
+func (p *printer) print(texts ...string) {
+	for _, text := range texts {
+		if text == "" {
+			continue
+		}
+		if p.space {
+			p.space = false
+			if p.col > 0 && !strings.ContainsAny(text[:1], " ,;)]") {
+				p.buf.WriteByte(' ')
+				p.col++
+			}
+		}
+		if p.col == 0 && !p.flat {
+			p.col = p.column()
+			p.buf.WriteString(strings.Repeat(" ", p.col))
+		}
+		p.buf.WriteString(text)
+		p.col += utf8.RuneCountInString(text)
+	}
+}
+
+// newline ends the current line unless it is empty.
+func (p *printer) newline() {
+	p.space = false
+	p.cont = false
+	if p.col > 0 {
+		p.buf.WriteByte('\n')
+		p.col = 0
+	}
+}
+
+// column returns the column the next text is printed at.
+func (p *printer) column() int {
+	if p.col == 0 && p.cont {
+		return (p.indent + 1) * indentWidth
+	}
+	if p.col == 0 {
+		return p.indent * indentWidth
+	}
+	return p.col
+}
+
+// fits reports whether the text printed by f fits on the current line.
+func (p *printer) fits(f func(p *printer)) bool {
+	q := &printer{file: p.file, src: p.src, flat: true}
+	f(q)
+	return p.column()+q.col <= lineWidth
+}
+
+// source returns the source text from pos up to end, as printed for nodes
+// with syntax errors.
+func (p *printer) source(pos, end token.Pos) string {
+	if p.src == nil || !pos.IsValid() || end < pos {
+		return ""
+	}
+	return string(p.src[p.file.Offset(pos):p.file.Offset(end)])
+}
+
+// token prints text, the token at pos in the source, after the comments
+// before it.
+func (p *printer) token(pos token.Pos, text string) {
+	p.intersperse(pos)
+	p.print(text)
+}
+
+// next returns the position of the first token at or after pos, skipping
+// white space and comments, for tokens the syntax tree has no position of.
+func (p *printer) next(pos token.Pos) token.Pos {
+	comments := p.comments
+	for offset := p.file.Offset(pos); offset < len(p.src); {
+		pos = p.file.Pos(offset)
+		for len(comments) > 0 && comments[0].end <= pos {
+			comments = comments[1:]
+		}
+		switch {
+		case len(comments) > 0 && comments[0].pos == pos:
+			offset = p.file.Offset(comments[0].end)
+		case unicode.IsSpace(p.src[offset]):
+			offset++
+		default:
+			return pos
+		}
+	}
+	return pos
+}
+
+// ----------------------------------------------------------------------------
+// Comments and line breaks
+
+// intersperse prints the comments before pos inside the current line, so
+// that they stay next to the tokens around them. A block comment is
+// followed by a space, a line comment by a line break, after which the
+// line continues indented.
+func (p *printer) intersperse(pos token.Pos) {
+	for len(p.comments) > 0 && p.comments[0].pos < pos {
+		c := p.comments[0]
+		inside := p.col > 0
+		if inside && !p.space && !strings.ContainsRune(" ([{", rune(p.buf.Bytes()[p.buf.Len()-1])) {
+			p.print(" ")
+		}
+		p.comment(c)
+		if strings.HasPrefix(c.text, "//") {
+			p.newline()
+			p.cont = inside
+		} else {
+			p.space = true
+		}
+	}
+}
+
+// linebreak ends the current line and prints the comments before pos, and
+// the blank lines before the text at pos. Between the last printed text
+// and the first comment or pos, it prints at least min and at most max
+// blank lines; elsewhere it keeps at most one of the blank lines in the
+// source.
+func (p *printer) linebreak(pos token.Pos, min, max int) {
+	if p.flush(pos, min, max) {
+		min, max = 0, 1
+	}
+	p.blank(p.blankLines(p.last, pos), min, max)
+}
+
+// flush ends the current line and prints the comments before pos. A
+// comment following the last printed text on its line stays there; the
+// others get lines of their own. flush reports whether there were any of
+// the latter.
+func (p *printer) flush(pos token.Pos, min, max int) bool {
+	for len(p.comments) > 0 && p.comments[0].pos < pos && p.col > 0 && p.trailing(p.comments[0]) {
+		p.print(" ")
+		p.comment(p.comments[0])
+	}
+	p.newline()
+	printed := false
+	for len(p.comments) > 0 && p.comments[0].pos < pos {
+		c := p.comments[0]
+		if printed {
+			p.blank(p.blankLines(p.last, c.pos), 0, 1)
+		} else {
+			p.blank(p.blankLines(p.last, c.pos), min, max)
+		}
+		p.comment(c)
+		p.newline()
+		printed = true
+	}
+	return printed
+}
+
+// trailing reports whether c follows the last printed text on its line in
+// the source, or is even inside of it.
+func (p *printer) trailing(c comment) bool {
+	return c.pos < p.last || !strings.ContainsRune(p.source(p.last, c.pos), '\n')
+}
+
+// comment prints c and consumes it. The lines of a block comment starting
+// with '*' are aligned with the comment start.
+func (p *printer) comment(c comment) {
+	p.comments = p.comments[1:]
+	for i, line := range strings.Split(c.text, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if i == 0 {
+			p.print(line)
+			continue
+		}
+		p.buf.WriteByte('\n')
+		p.col = 0
+		if trimmed := strings.TrimLeft(line, " \t"); strings.HasPrefix(trimmed, "*") {
+			p.print(" ", trimmed)
+		} else {
+			// keep other lines as they are
+			p.buf.WriteString(line)
+			p.col = utf8.RuneCountInString(line)
+		}
+	}
+	if c.end > p.last {
+		p.last = c.end
+	}
+}
+
+// hasComments reports whether there are comments between pos and end.
+func (p *printer) hasComments(pos, end token.Pos) bool {
+	for _, c := range p.comments {
+		if c.pos >= end {
+			break
+		}
+		if c.pos > pos {
+			return true
+		}
+	}
+	return false
+}
+
+// blankLines returns the number of blank lines in the source between pos
+// and end.
+func (p *printer) blankLines(pos, end token.Pos) int {
+	n := strings.Count(p.source(pos, end), "\n") - 1
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// blank prints n blank lines, but at least min and at most max, and none at
+// the start of the output.
+func (p *printer) blank(n, min, max int) {
+	if n < min {
+		n = min
+	}
+	if n > max {
+		n = max
+	}
+	if p.buf.Len() == 0 {
+		return
+	}
+	for ; n > 0; n-- {
+		p.buf.WriteByte('\n')
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Lists and blocks
+
+// list prints a comma-separated list of nodes enclosed in open and close,
+// like the arguments of a call. lpos and rpos are the positions of the
+// delimiters in the source. If the list does not fit on the current line
+// or contains comments, it is broken with one node per line.
+func (p *printer) list(lpos token.Pos, open string, list []ast.Node, close string, rpos token.Pos) {
+	p.intersperse(lpos)
+	if len(list) == 0 && !strings.ContainsRune(p.source(lpos, rpos), '\n') {
+		p.print(open)
+		p.token(rpos, close)
+		return
+	}
+	if p.flat || !p.hasComments(lpos, rpos) && p.fits(func(p *printer) { p.flatList(open, list, close) }) {
+		p.flatList(open, list, close)
+		return
+	}
+	p.brokenList(lpos, open, list, close, rpos)
+}
+
+func (p *printer) flatList(open string, list []ast.Node, close string) {
+	p.print(open)
+	for i, x := range list {
+		if i > 0 {
+			p.token(p.next(list[i-1].End()), ", ")
+		}
+		p.expr(x)
+	}
+	p.print(close)
+}
+
+func (p *printer) brokenList(lpos token.Pos, open string, list []ast.Node, close string, rpos token.Pos) {
+	p.token(lpos, open)
+	p.last = lpos + 1
+	p.indent++
+	for i, x := range list {
+		p.linebreak(x.Pos(), 0, 0)
+		p.expr(x)
+		if i < len(list)-1 {
+			p.token(p.next(x.End()), ",")
+		}
+		p.last = x.End()
+	}
+	p.linebreak(rpos, 0, 0)
+	p.indent--
+	p.print(close)
+	p.last = rpos + 1
+}
+
+// block prints a braced list of statements or declarations with print,
+// one per line. spacing returns the minimum and maximum number of blank
+// lines between two of them.
+func (p *printer) block(lbrace token.Pos, list []ast.Node, rbrace token.Pos, spacing func(prev, next ast.Node) (min, max int), print func(p *printer, n ast.Node)) {
+	p.intersperse(lbrace)
+	if len(list) == 0 && !p.hasComments(lbrace, rbrace) {
+		p.print("{}")
+		p.last = rbrace + 1
+		return
+	}
+	p.print("{")
+	p.last = lbrace + 1
+	p.indent++
+	for i, n := range list {
+		min, max := 0, 0
+		if i > 0 {
+			min, max = spacing(list[i-1], n)
+		}
+		p.linebreak(n.Pos(), min, max)
+		print(p, n)
+		p.last = n.End()
+	}
+	p.linebreak(rbrace, 0, 0)
+	p.indent--
+	p.print("}")
+	p.last = rbrace + 1
+}
+
+// stmtSpacing keeps a blank line between statements.
+func stmtSpacing(prev, next ast.Node) (min, max int) {
+	return 0, 1
+}
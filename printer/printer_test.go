@@ -0,0 +1,356 @@
+package printer
+
+import (
+	"testing"
+
+	"github.com/ToQoz/gopwt/assert"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		// directives and declarations
+		{`pragma   solidity  >=0.8.0  <0.9.0 ;
+import './A.sol' ;
+import {B as C,D} from "./B.sol";
+contract A is B,C{}
+interface I{function f()external;}`, `pragma solidity >=0.8.0 <0.9.0;
+import "./A.sol";
+import {B as C, D} from "./B.sol";
+
+contract A is B, C {}
+
+
+interface I {
+    function f() external;
+}
+`},
+		// members
+		{`contract Token{
+using SafeMath for uint256;
+struct Account{uint256 balance;mapping(address=>uint256) allowances;}
+enum State{Active,Frozen}
+event Transfer(address indexed from,address indexed to,uint256 value);
+uint256 public constant MAX=1000;
+address immutable owner;
+modifier onlyOwner{require(msg.sender==owner);_;}
+constructor(uint256 supply)Base(supply){}
+function f(uint256 x)onlyOwner external view override returns(uint256){return x*2;}
+}`, `contract Token {
+    using SafeMath for uint256;
+
+    struct Account {
+        uint256 balance;
+        mapping(address => uint256) allowances;
+    }
+
+    enum State {
+        Active,
+        Frozen
+    }
+
+    event Transfer(address indexed from, address indexed to, uint256 value);
+    uint256 public constant MAX = 1000;
+    address immutable owner;
+
+    modifier onlyOwner {
+        require(msg.sender == owner);
+        _;
+    }
+
+    constructor(uint256 supply) Base(supply) {}
+
+    function f(uint256 x) external view override onlyOwner returns (uint256) {
+        return x * 2;
+    }
+}
+`},
+		// statements
+		{`function f(uint256[] memory xs)pure returns(uint256 sum,bool){
+for(uint256 i=0;i<xs.length;i++){sum+=xs[i];}
+if(sum>10)return(sum,true);else if(sum==0){revert Empty();}else{sum=-sum;}
+while(true)break;
+do{sum--;}while(sum>0);
+(uint256 a,,bool c)=g();
+unchecked{sum++;}
+}`, `function f(uint256[] memory xs) pure returns (uint256 sum, bool) {
+    for (uint256 i = 0; i < xs.length; i++) {
+        sum += xs[i];
+    }
+    if (sum > 10)
+        return (sum, true);
+    else if (sum == 0) {
+        revert Empty();
+    } else {
+        sum = -sum;
+    }
+    while (true)
+        break;
+    do {
+        sum--;
+    } while (sum > 0);
+    (uint256 a, , bool c) = g();
+    unchecked {
+        sum++;
+    }
+}
+`},
+		// expressions
+		{`contract C{function f()public{
+x=a?b:c;
+y=new uint256[](3);
+z=type(uint256).max;
+t.call{value:1,gas:2}("");
+f({a:1,b:2});
+g(xs[1:],[1,2,3]);
+emit E(- -x,!y,1 ether);
+try t.f() returns(uint256 v){v;}catch Error(string memory r){r;}catch{}
+}}`, `contract C {
+    function f() public {
+        x = a ? b : c;
+        y = new uint256[](3);
+        z = type(uint256).max;
+        t.call{value: 1, gas: 2}("");
+        f({a: 1, b: 2});
+        g(xs[1:], [1, 2, 3]);
+        emit E(- -x, !y, 1 ether);
+        try t.f() returns (uint256 v) {
+            v;
+        } catch Error(string memory r) {
+            r;
+        } catch {}
+    }
+}
+`},
+		// comments
+		{`// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+/**
+   * @title A
+     */
+contract A { // trailing
+
+
+  uint a; /* inline */ uint b;
+  // dangling
+}`, `// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+/**
+ * @title A
+ */
+contract A { // trailing
+    uint a; /* inline */
+    uint b;
+    // dangling
+}
+`},
+		// line wrapping
+		{`contract LongContractName is FirstVeryLongBaseContract, SecondVeryLongBaseContract {
+function transferFrom(address sender, address recipient, uint256 amount, bytes calldata data) public override returns (bool) {
+emitSomething(sender, recipient, amount, someOtherArgument, yetAnotherArgument, last);
+}
+function approve(address spender, uint256 amount) external virtual override returns (bool) {}
+}`, `contract LongContractName is
+    FirstVeryLongBaseContract,
+    SecondVeryLongBaseContract
+{
+    function transferFrom(
+        address sender,
+        address recipient,
+        uint256 amount,
+        bytes calldata data
+    )
+        public
+        override
+        returns (bool)
+    {
+        emitSomething(
+            sender,
+            recipient,
+            amount,
+            someOtherArgument,
+            yetAnotherArgument,
+            last
+        );
+    }
+
+    function approve(address spender, uint256 amount)
+        external
+        virtual
+        override
+        returns (bool)
+    {}
+}
+`},
+		// inline assembly
+		{`contract C{function f(){
+assembly ("memory-safe"){let x:=add(1,2)
+if lt(x,3){x:=0}
+switch x case 0{x:=1}default{x:=2}
+for{let i:=0}lt(i,10){i:=add(i,1)}{mstore(i,x)}
+function g(a)->b{b:=a}
+}}}`, `contract C {
+    function f() {
+        assembly ("memory-safe") {
+            let x := add(1, 2)
+            if lt(x, 3) {
+                x := 0
+            }
+            switch x
+            case 0 {
+                x := 1
+            }
+            default {
+                x := 2
+            }
+            for { let i := 0 } lt(i, 10) { i := add(i, 1) } {
+                mstore(i, x)
+            }
+            function g(a) -> b {
+                b := a
+            }
+        }
+    }
+}
+`},
+		// comments inside lines stay next to their tokens
+		{`uint constant X = /* c1 */ 1;
+function f /* c2 */ (uint a, bool b) pure /* c3 */ returns (uint) /* c4 */ {
+return a /* x */ + g(/* none */) * (b ? /* y */ 1 : 2);
+}
+contract C {
+function h(uint a,
+// the owner
+address owner) external {}
+function k(uint /* amount */ a) public /* only */ onlyOwner {}
+function m() public { uint y = // why
+1; }
+}`, `uint constant X = /* c1 */ 1;
+
+
+function f /* c2 */ (uint a, bool b) pure /* c3 */ returns (uint) /* c4 */ {
+    return a /* x */ + g(/* none */) * (b ? /* y */ 1 : 2);
+}
+
+
+contract C {
+    function h(
+        uint a,
+        // the owner
+        address owner
+    )
+        external
+    {}
+
+    function k(
+        uint /* amount */ a
+    )
+        public
+        /* only */ onlyOwner
+    {}
+
+    function m() public {
+        uint y = // why
+            1;
+    }
+}
+`},
+		// comments around else
+		{`function f() {
+if (a) x = 1; else /* c */ x = 2;
+if (a) { x = 1; }
+// otherwise
+else { x = 2; }
+}`, `function f() {
+    if (a)
+        x = 1;
+    else /* c */
+        x = 2;
+    if (a) {
+        x = 1;
+    }
+    // otherwise
+    else {
+        x = 2;
+    }
+}
+`},
+		// string quotes and blank lines between top-level declarations
+		{`import './A.sol';
+type Price is uint128;
+uint constant K = 1;
+string constant S = 'abc';
+string constant Q = 'say "hi"';
+bytes constant H = hex'00ff';
+function f() pure returns (string memory) { return unicode'é'; }
+function g() {}`, `import "./A.sol";
+
+type Price is uint128;
+
+
+uint constant K = 1;
+
+
+string constant S = "abc";
+
+
+string constant Q = 'say "hi"';
+
+
+bytes constant H = hex"00ff";
+
+
+function f() pure returns (string memory) {
+    return unicode"é";
+}
+
+
+function g() {}
+`},
+	}
+	for _, tt := range tests {
+		got, err := Source([]byte(tt.src))
+		assert.Require(t, err == nil, tt.src)
+		assert.OK(t, string(got) == tt.expected, tt.src)
+
+		again, err := Source(got)
+		assert.Require(t, err == nil, tt.src)
+		assert.OK(t, string(again) == string(got), tt.src)
+	}
+}
+
+func TestSource_Errors(t *testing.T) {
+	for _, src := range []string{
+		// syntax error
+		"contract C {",
+		// user-defined operators are not parsed yet
+		"using {add as +} for Fixed global;",
+	} {
+		_, err := Source([]byte(src))
+		assert.OK(t, err != nil, src)
+	}
+}
+
+func TestSameTokens(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"x = a - b;", "x = a - b;", true},
+		{"x = a - b;", "x = b - a;", false},
+		{`s = "a";`, `s = "b";`, false},
+		{"x = /* c */ 1;", "x = 1; /* c */", false},
+		{"x = 1; // a", "x = 1; // b", false},
+		// changes of the printer
+		{"s = 'a';", `s = "a";`, true},
+		{"/*\n   * a\n   */", "/*\n * a\n */", true},
+		{"function f() onlyOwner view override(A, B) public {}", "function f() public view override(A, B) onlyOwner {}", true},
+		{"function f() override(A, B) {}", "function f() override(B, A) {}", false},
+	}
+	for _, tt := range tests {
+		assert.OK(t, sameTokens([]rune(tt.a), []rune(tt.b)) == tt.expected, tt.a+" | "+tt.b)
+	}
+}
@@ -0,0 +1,41 @@
+//go:build go1.18
+// +build go1.18
+
+package scanner
+
+import (
+	"testing"
+
+	"github.com/blockchain-labs-org/solzaemon/token"
+)
+
+func FuzzScan(f *testing.F) {
+	for _, seed := range []string{
+		``,
+		` `,
+		`contract A is B { uint256 x = 0x1_f * 2.5e3 ether; }`,
+		`"abc\x4`,
+		`hex"0`,
+		`unicode'😃`,
+		`/* unterminated`,
+		`/// doc`,
+		`a >>>= b`,
+		"\x00\xff#@",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		runes := []rune(src)
+		f := token.NewFileSet().AddFile("", -1, len(runes))
+		s := NewScanner(f, runes, func(pos token.Position, msg string) {}, ScanComments)
+		for i := 0; ; i++ {
+			_, tok, _ := s.Scan()
+			if tok == token.EOF {
+				break
+			}
+			if i > len(runes) {
+				t.Fatalf("Scan does not reach EOF for %q", src)
+			}
+		}
+	})
+}
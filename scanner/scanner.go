@@ -1,6 +1,8 @@
 package scanner
 
 import (
+	"fmt"
+	"strings"
 	"unicode"
 
 	"github.com/blockchain-labs-org/solzaemon/token"
@@ -14,41 +16,73 @@ var tokMap = map[rune]token.Token{
 	'[': token.LBRACK,
 	']': token.RBRACK,
 	';': token.SEMICOLON,
+	',': token.COMMA,
 	'.': token.PERIOD,
-	'^': token.XOR,
+	':': token.COLON,
+	'?': token.QUESTION,
+	'~': token.TILDE,
 }
 
+// A Mode value is a set of flags (or 0). They control scanner behavior.
+type Mode uint
+
+const (
+	// ScanComments makes Scan return comments as token.COMMENT or
+	// token.NATSPEC instead of skipping them.
+	ScanComments Mode = 1 << iota
+)
+
+// An ErrorHandler may be provided to NewScanner. If a syntax error is
+// encountered and a handler was installed, the handler is called with a
+// position and an error message. The position points to the beginning of
+// the offending token.
+type ErrorHandler func(pos token.Position, msg string)
+
 // Scanner is lexical scanner
 type Scanner struct {
 	file   *token.File
 	src    []rune
+	err    ErrorHandler
+	mode   Mode
 	pos    int
 	offset token.Pos
+
+	ErrorCount int // number of errors encountered
 }
 
-func NewScanner(f *token.File, src []rune) *Scanner {
-	return &Scanner{src: src, file: f}
+// NewScanner returns a scanner for src, whose size must match f.Size().
+// Line information is added to f as src is scanned.
+func NewScanner(f *token.File, src []rune, err ErrorHandler, mode Mode) *Scanner {
+	if f.Size() != len(src) {
+		panic(fmt.Sprintf("file size (%d) does not match src len (%d)", f.Size(), len(src)))
+	}
+	return &Scanner{src: src, file: f, err: err, mode: mode, offset: f.Pos(0)}
 }
 
+func (s *Scanner) error(pos token.Pos, msg string) {
+	if s.err != nil {
+		s.err(s.file.Position(pos), msg)
+	}
+	s.ErrorCount++
+}
+
+const eof = -1
+
 func (s *Scanner) peek() rune {
-	return s.src[s.pos]
+	return s.lookahead(0)
 }
 
 func (s *Scanner) next() rune {
 	if s.pos >= len(s.src) {
-		return 0
+		return eof
 	}
 
 	ret := s.src[s.pos]
-
+	s.offset++
+	s.pos++
 	if ret == '\n' {
 		s.file.AddLine(s.pos)
-	} else {
-		s.file.AddCharacter(s.pos)
 	}
-
-	s.offset++
-	s.pos++
 	return ret
 }
 
@@ -58,57 +92,170 @@ func (s *Scanner) skipBlank() {
 	}
 }
 
+// Scan scans the next token and returns the token position, the token, and
+// its literal string. At the end of the source, Scan returns token.EOF.
+//
+// Scan never panics. Malformed input is reported to the error handler and
+// yields token.ILLEGAL or a best-effort token.
 func (s *Scanner) Scan() (pos token.Pos, tok token.Token, lit string) {
-	if s.pos >= len(s.src) {
-		return 0, 0, ""
-	}
+scanAgain:
 	s.skipBlank()
 	pos = s.offset
+	if s.pos >= len(s.src) {
+		tok = token.EOF
+		return
+	}
 	switch ch := s.peek(); {
-	case ch == '"':
+	case ch == '"' || ch == '\'':
 		tok = token.STRING
-		lit = s.scanString()
+		lit = s.scanString(tok, pos)
 		return
 	case isLetter(ch):
-		tok = token.IDENT
 		lit = s.scanIdent()
+		tok = token.Lookup(lit)
+		if q := s.lookahead(0); q == '"' || q == '\'' {
+			switch lit {
+			case "hex":
+				tok = token.HEX_STRING
+				lit += s.scanString(tok, pos)
+			case "unicode":
+				tok = token.UNICODE_STRING
+				lit += s.scanString(tok, pos)
+			}
+		}
 		return
-	case isDigit(ch):
-		tok = token.INT
-		lit = s.scanNumber()
+	case isDigit(ch) || ch == '.' && isDigit(s.lookahead(1)):
+		tok, lit = s.scanNumber()
 		return
-	case ch == '=':
+	default:
+		start := s.pos
 		s.next()
-		if s.peek() == '=' {
-			s.next()
-			tok = token.EQ
-			lit = "=="
-		} else {
-			tok = token.ASSIGN
-			lit = string(ch)
+		switch ch {
+		case '+':
+			tok = s.switch3(token.ADD, token.ADD_ASSIGN, '+', token.INC)
+		case '-':
+			if s.peek() == '>' {
+				s.next()
+				tok = token.ARROW
+			} else {
+				tok = s.switch3(token.SUB, token.SUB_ASSIGN, '-', token.DEC)
+			}
+		case '*':
+			tok = s.switch3(token.MUL, token.MUL_ASSIGN, '*', token.POW)
+		case '/':
+			if s.peek() == '/' || s.peek() == '*' {
+				tok = s.scanComment()
+				if s.mode&ScanComments == 0 {
+					goto scanAgain
+				}
+			} else {
+				tok = s.switch2(token.QUO, token.QUO_ASSIGN)
+			}
+		case '%':
+			tok = s.switch2(token.REM, token.REM_ASSIGN)
+		case '&':
+			tok = s.switch3(token.AND, token.AND_ASSIGN, '&', token.LAND)
+		case '|':
+			tok = s.switch3(token.OR, token.OR_ASSIGN, '|', token.LOR)
+		case '^':
+			tok = s.switch2(token.XOR, token.XOR_ASSIGN)
+		case '<':
+			tok = s.switch4(token.LSS, token.LEQ, '<', token.SHL, token.SHL_ASSIGN)
+		case '>':
+			if s.peek() == '>' {
+				s.next()
+				tok = s.switch4(token.SHR, token.SHR_ASSIGN, '>', token.SAR, token.SAR_ASSIGN)
+			} else {
+				tok = s.switch2(token.GTR, token.GEQ)
+			}
+		case '=':
+			tok = s.switch3(token.ASSIGN, token.EQ, '>', token.DARROW)
+		case '!':
+			tok = s.switch2(token.NOT, token.NEQ)
+		default:
+			if tk, ok := tokMap[ch]; ok {
+				tok = tk
+			} else {
+				s.error(pos, fmt.Sprintf("illegal character %#U", ch))
+				tok = token.ILLEGAL
+			}
+		}
+		lit = string(s.src[start:s.pos])
+	}
+	return
+}
+
+// Peek returns the token that the next call of Scan will return, without
+// advancing the scanner or reporting errors.
+func (s *Scanner) Peek() token.Token {
+	ahead := *s
+	ahead.err = nil
+	_, tok, _ := ahead.Scan()
+	return tok
+}
+
+// PeekN returns the n tokens that the next calls of Scan will return,
+// without advancing the scanner or reporting errors. It returns fewer
+// tokens at the end of the source.
+func (s *Scanner) PeekN(n int) []token.Token {
+	ahead := *s
+	ahead.err = nil
+	var toks []token.Token
+	for len(toks) < n {
+		_, tok, _ := ahead.Scan()
+		if tok == token.EOF {
+			break
 		}
-	case ch == '*':
+		toks = append(toks, tok)
+	}
+	return toks
+}
+
+// Seek continues scanning at pos, which must be a position in the file
+// being scanned. Text scanned by another scanner, like an embedded Yul
+// block, can be skipped this way.
+func (s *Scanner) Seek(pos token.Pos) {
+	s.pos = s.file.Offset(pos)
+	s.offset = pos
+}
+
+// switch2 returns tok1 if the next rune is '=', tok0 otherwise.
+func (s *Scanner) switch2(tok0, tok1 token.Token) token.Token {
+	if s.peek() == '=' {
 		s.next()
-		if s.peek() == '*' {
+		return tok1
+	}
+	return tok0
+}
+
+// switch3 is like switch2 but also returns tok2 if the next rune is ch2.
+func (s *Scanner) switch3(tok0, tok1 token.Token, ch2 rune, tok2 token.Token) token.Token {
+	if s.peek() == '=' {
+		s.next()
+		return tok1
+	}
+	if s.peek() == ch2 {
+		s.next()
+		return tok2
+	}
+	return tok0
+}
+
+// switch4 is like switch3 but returns tok3 if ch2 is followed by '='.
+func (s *Scanner) switch4(tok0, tok1 token.Token, ch2 rune, tok2, tok3 token.Token) token.Token {
+	if s.peek() == '=' {
+		s.next()
+		return tok1
+	}
+	if s.peek() == ch2 {
+		s.next()
+		if s.peek() == '=' {
 			s.next()
-			tok = token.POW
-			lit = "**"
-		} else {
-			tok = token.MUL
-			lit = string(ch)
+			return tok3
 		}
-	default:
-		tk, ok := tokMap[ch]
-		if ok {
-			tok = tk
-			lit = string(ch)
-		} else {
-			tok = token.ILLEGAL
-			lit = string(ch)
-		}
-		s.next()
+		return tok2
 	}
-	return
+	return tok0
 }
 
 func (s *Scanner) scanUntilSemicolon() string {
@@ -117,6 +264,8 @@ func (s *Scanner) scanUntilSemicolon() string {
 done:
 	for {
 		switch ch := s.next(); {
+		case ch == eof:
+			break done
 		case ch == ';':
 			ret = append(ret, ch)
 			if started {
@@ -131,6 +280,41 @@ done:
 
 }
 
+// scanComment scans the rest of a comment whose leading '/' has already
+// been consumed. Comments starting with "///" or "/**" are NatSpec, except
+// for "////...", "/***..." and the empty block comment "/**/".
+func (s *Scanner) scanComment() token.Token {
+	tok := token.COMMENT
+	if s.next() == '/' {
+		// line comment
+		if s.peek() == '/' && s.lookahead(1) != '/' {
+			tok = token.NATSPEC
+		}
+		for ch := s.peek(); ch != '\n' && ch != eof; ch = s.peek() {
+			s.next()
+		}
+		return tok
+	}
+
+	// block comment
+	pos := s.offset - 2
+	if s.peek() == '*' && s.lookahead(1) != '*' && s.lookahead(1) != '/' && s.lookahead(1) != eof {
+		tok = token.NATSPEC
+	}
+	for {
+		ch := s.next()
+		if ch == eof {
+			s.error(pos, "comment not terminated")
+			break
+		}
+		if ch == '*' && s.peek() == '/' {
+			s.next()
+			break
+		}
+	}
+	return tok
+}
+
 func (s *Scanner) scanIdent() string {
 	var ret []rune
 	for {
@@ -144,38 +328,157 @@ func (s *Scanner) scanIdent() string {
 	return string(ret)
 }
 
-func (s *Scanner) scanString() string {
-	var ret []rune
-	started := false
-done:
+// scanString scans a string literal of kind token.STRING, token.HEX_STRING
+// or token.UNICODE_STRING, starting at its opening quote. pos is the
+// position of the literal including its prefix.
+func (s *Scanner) scanString(kind token.Token, pos token.Pos) string {
+	start := s.pos
+	quote := s.next()
 	for {
-		switch ch := s.next(); {
-		case ch == '"':
-			ret = append(ret, ch)
-			if started {
-				break done
+		ch := s.lookahead(0)
+		if ch == quote {
+			s.next()
+			break
+		}
+		if ch == '\n' || s.pos >= len(s.src) {
+			s.error(pos, "string literal not terminated")
+			break
+		}
+		if ch == '\\' && kind != token.HEX_STRING {
+			s.scanEscape(quote)
+			continue
+		}
+		if kind == token.STRING && (ch < ' ' || ch > '~') && ch != '\t' {
+			s.error(s.offset, "invalid character in string literal; use a unicode\"...\" literal for non-ASCII characters")
+		}
+		s.next()
+	}
+
+	lit := string(s.src[start:s.pos])
+	if kind == token.HEX_STRING && !isHexString(lit) {
+		s.error(pos, "hex string literal must consist of an even number of hex digits, optionally separated by underscores between bytes")
+	}
+	return lit
+}
+
+// scanEscape scans an escape sequence starting at its backslash.
+func (s *Scanner) scanEscape(quote rune) {
+	pos := s.offset
+	s.next()
+	var n int
+	switch ch := s.lookahead(0); ch {
+	case quote, '\'', '"', '\\', 'n', 'r', 't', '\n':
+		s.next()
+		return
+	case 'x':
+		n = 2
+	case 'u':
+		n = 4
+	default:
+		if s.pos < len(s.src) {
+			s.error(pos, "invalid escape sequence")
+		}
+		return
+	}
+	s.next()
+	for i := 0; i < n; i++ {
+		if !isHex(s.lookahead(0)) {
+			s.error(pos, "invalid escape sequence")
+			return
+		}
+		s.next()
+	}
+}
+
+// isHexString reports whether the quoted part of lit is a valid hex string,
+// like hex"00ff" or hex"00_ff".
+func isHexString(lit string) bool {
+	lit = strings.TrimPrefix(lit, "hex")
+	if len(lit) < 2 {
+		return false
+	}
+	digits := lit[1 : len(lit)-1]
+	for i, chunk := range strings.Split(digits, "_") {
+		if len(chunk)%2 != 0 || i > 0 && len(chunk) == 0 {
+			return false
+		}
+		for _, ch := range chunk {
+			if !isHex(ch) {
+				return false
 			}
-			started = true
-		default:
-			ret = append(ret, ch)
 		}
 	}
-	return string(ret)
+	return !strings.HasPrefix(digits, "_") && !strings.HasSuffix(digits, "_")
 }
 
-func (s *Scanner) scanNumber() string {
-	var ret []rune
-done:
-	for {
-		switch ch := s.peek(); {
-		case isDigit(ch):
+// scanNumber scans a decimal or hexadecimal integer (token.INT), or a
+// number with a fraction or an exponent (token.RATIONAL). Underscores
+// may separate digits.
+func (s *Scanner) scanNumber() (token.Token, string) {
+	start, pos := s.pos, s.offset
+	tok := token.INT
+	if s.lookahead(0) == '0' && s.lookahead(1) == 'x' {
+		s.next()
+		s.next()
+		if s.scanDigits(isHex) == 0 {
+			s.error(pos, "hexadecimal literal has no digits")
+		}
+		return tok, string(s.src[start:s.pos])
+	}
+
+	s.scanDigits(isDigit)
+	if s.lookahead(0) == '.' && isDigit(s.lookahead(1)) {
+		tok = token.RATIONAL
+		s.next()
+		s.scanDigits(isDigit)
+	}
+	// an e followed by a letter starts an identifier, as in 1ether
+	if ch := s.lookahead(0); (ch == 'e' || ch == 'E') && !isLetter(s.lookahead(1)) {
+		tok = token.RATIONAL
+		s.next()
+		if s.lookahead(0) == '-' {
 			s.next()
-			ret = append(ret, ch)
-		default:
-			break done
+		}
+		if s.scanDigits(isDigit) == 0 {
+			s.error(pos, "exponent has no digits")
 		}
 	}
-	return string(ret)
+	return tok, string(s.src[start:s.pos])
+}
+
+// scanDigits scans digits separated by single underscores, and returns the
+// number of digits.
+func (s *Scanner) scanDigits(isDigit func(rune) bool) int {
+	n := 0
+	sep := true // at the start or after an underscore
+	invalid := token.Pos(-1)
+	for ch := s.lookahead(0); isDigit(ch) || ch == '_'; ch = s.lookahead(0) {
+		if ch == '_' {
+			if sep && invalid < 0 {
+				invalid = s.offset
+			}
+			sep = true
+		} else {
+			n++
+			sep = false
+		}
+		s.next()
+	}
+	if sep && n > 0 && invalid < 0 {
+		invalid = s.offset - 1
+	}
+	if invalid >= 0 {
+		s.error(invalid, "'_' must separate successive digits")
+	}
+	return n
+}
+
+// lookahead returns the rune n runes after the current one, or eof past the end.
+func (s *Scanner) lookahead(n int) rune {
+	if s.pos+n >= len(s.src) {
+		return eof
+	}
+	return s.src[s.pos+n]
 }
 
 func isBlank(ch rune) bool {
@@ -189,3 +492,7 @@ func isLetter(ch rune) bool {
 func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
+
+func isHex(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
@@ -1,16 +1,22 @@
 package scanner
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/ToQoz/gopwt/assert"
 	"github.com/blockchain-labs-org/solzaemon/token"
 )
 
+func newScanner(src string, err ErrorHandler, mode Mode) *Scanner {
+	runes := []rune(src)
+	return NewScanner(token.NewFileSet().AddFile("", -1, len(runes)), runes, err, mode)
+}
+
 func TestScan(t *testing.T) {
 	{
 		// pragma solidity ^0.4.23
-		s := NewScanner(token.NewFile(), []rune(`pragma solidity ^0.4.23;
+		s := newScanner(`pragma solidity ^0.4.23;
 import "../token/ERC20/StandardToken.sol";
 
 contract SimpleToken is StandardToken {
@@ -24,21 +30,21 @@ contract SimpleToken is StandardToken {
 		totalSupply_ = INITIAL_SUPPLY;
 		balances[msg.sender] = INITIAL_SUPPLY;
 	}
-}`))
+}`, nil, 0)
 		expected := []struct {
 			tok token.Token
 			lit string
 		}{
-			{token.IDENT, `pragma`}, {token.IDENT, `solidity`}, {token.XOR, `^`}, {token.INT, `0`}, {token.PERIOD, `.`}, {token.INT, `4`}, {token.PERIOD, `.`}, {token.INT, `23`}, {token.SEMICOLON, `;`},
-			{token.IDENT, `import`}, {token.STRING, `"../token/ERC20/StandardToken.sol"`}, {token.SEMICOLON, `;`},
-			{token.IDENT, `contract`}, {token.IDENT, `SimpleToken`}, {token.IDENT, `is`}, {token.IDENT, `StandardToken`}, {token.LBRACE, `{`},
-			{token.IDENT, `string`}, {token.IDENT, `public`}, {token.IDENT, `constant`}, {token.IDENT, `name`}, {token.ASSIGN, `=`}, {token.STRING, `"SimpleToken"`}, {token.SEMICOLON, `;`},
-			{token.IDENT, `string`}, {token.IDENT, `public`}, {token.IDENT, `constant`}, {token.IDENT, `symbol`}, {token.ASSIGN, `=`}, {token.STRING, `"SIM"`}, {token.SEMICOLON, `;`},
-			{token.IDENT, `uint8`}, {token.IDENT, `public`}, {token.IDENT, `constant`}, {token.IDENT, `decimals`}, {token.ASSIGN, `=`}, {token.INT, `18`}, {token.SEMICOLON, `;`},
-			{token.IDENT, `uint256`}, {token.IDENT, `public`}, {token.IDENT, `constant`}, {token.IDENT, `INITIAL_SUPPLY`}, {token.ASSIGN, `=`},
+			{token.PRAGMA, `pragma`}, {token.IDENT, `solidity`}, {token.XOR, `^`}, {token.RATIONAL, `0.4`}, {token.RATIONAL, `.23`}, {token.SEMICOLON, `;`},
+			{token.IMPORT, `import`}, {token.STRING, `"../token/ERC20/StandardToken.sol"`}, {token.SEMICOLON, `;`},
+			{token.CONTRACT, `contract`}, {token.IDENT, `SimpleToken`}, {token.IS, `is`}, {token.IDENT, `StandardToken`}, {token.LBRACE, `{`},
+			{token.IDENT, `string`}, {token.PUBLIC, `public`}, {token.CONSTANT, `constant`}, {token.IDENT, `name`}, {token.ASSIGN, `=`}, {token.STRING, `"SimpleToken"`}, {token.SEMICOLON, `;`},
+			{token.IDENT, `string`}, {token.PUBLIC, `public`}, {token.CONSTANT, `constant`}, {token.IDENT, `symbol`}, {token.ASSIGN, `=`}, {token.STRING, `"SIM"`}, {token.SEMICOLON, `;`},
+			{token.IDENT, `uint8`}, {token.PUBLIC, `public`}, {token.CONSTANT, `constant`}, {token.IDENT, `decimals`}, {token.ASSIGN, `=`}, {token.INT, `18`}, {token.SEMICOLON, `;`},
+			{token.IDENT, `uint256`}, {token.PUBLIC, `public`}, {token.CONSTANT, `constant`}, {token.IDENT, `INITIAL_SUPPLY`}, {token.ASSIGN, `=`},
 			/* - */ {token.INT, `10000`}, {token.MUL, "*"}, {token.LPAREN, "("}, {token.INT, "10"}, {token.POW, "**"},
 			/* - */ {token.IDENT, "uint256"}, {token.LPAREN, "("}, {token.IDENT, "decimals"}, {token.RPAREN, ")"}, {token.RPAREN, ")"}, {token.SEMICOLON, `;`},
-			{token.IDENT, `constructor`}, {token.LPAREN, "("}, {token.RPAREN, ")"}, {token.IDENT, `public`}, {token.LBRACE, `{`},
+			{token.CONSTRUCTOR, `constructor`}, {token.LPAREN, "("}, {token.RPAREN, ")"}, {token.PUBLIC, `public`}, {token.LBRACE, `{`},
 			{token.IDENT, `totalSupply_`}, {token.ASSIGN, "="}, {token.IDENT, "INITIAL_SUPPLY"}, {token.SEMICOLON, `;`},
 			{token.IDENT, `balances`}, {token.LBRACK, "["}, {token.IDENT, "msg"}, {token.PERIOD, `.`}, {token.IDENT, "sender"}, {token.RBRACK, "]"}, {token.ASSIGN, "="}, {token.IDENT, "INITIAL_SUPPLY"}, {token.SEMICOLON, `;`},
 			{token.RBRACE, `}`},
@@ -50,3 +56,332 @@ contract SimpleToken is StandardToken {
 		}
 	}
 }
+
+func TestScanOperators(t *testing.T) {
+	tests := []struct {
+		src string
+		tok token.Token
+	}{
+		{`+`, token.ADD}, {`-`, token.SUB}, {`*`, token.MUL}, {`**`, token.POW}, {`/`, token.QUO}, {`%`, token.REM},
+		{`&`, token.AND}, {`|`, token.OR}, {`^`, token.XOR}, {`<<`, token.SHL}, {`>>`, token.SHR}, {`>>>`, token.SAR},
+		{`+=`, token.ADD_ASSIGN}, {`-=`, token.SUB_ASSIGN}, {`*=`, token.MUL_ASSIGN}, {`/=`, token.QUO_ASSIGN}, {`%=`, token.REM_ASSIGN},
+		{`&=`, token.AND_ASSIGN}, {`|=`, token.OR_ASSIGN}, {`^=`, token.XOR_ASSIGN}, {`<<=`, token.SHL_ASSIGN}, {`>>=`, token.SHR_ASSIGN}, {`>>>=`, token.SAR_ASSIGN},
+		{`&&`, token.LAND}, {`||`, token.LOR}, {`++`, token.INC}, {`--`, token.DEC},
+		{`=`, token.ASSIGN}, {`==`, token.EQ}, {`!=`, token.NEQ}, {`<`, token.LSS}, {`>`, token.GTR}, {`<=`, token.LEQ}, {`>=`, token.GEQ}, {`!`, token.NOT}, {`~`, token.TILDE},
+		{`=>`, token.DARROW}, {`->`, token.ARROW}, {`?`, token.QUESTION},
+		{`(`, token.LPAREN}, {`[`, token.LBRACK}, {`{`, token.LBRACE}, {`,`, token.COMMA}, {`.`, token.PERIOD},
+		{`)`, token.RPAREN}, {`]`, token.RBRACK}, {`}`, token.RBRACE}, {`;`, token.SEMICOLON}, {`:`, token.COLON},
+	}
+	for _, tt := range tests {
+		s := newScanner(tt.src+" x;", nil, 0)
+		_, tok, lit := s.Scan()
+		assert.OK(t, tok == tt.tok, tt.src)
+		assert.OK(t, lit == tt.src, tt.src)
+		_, tok, lit = s.Scan()
+		assert.OK(t, tok == token.IDENT && lit == "x", tt.src)
+	}
+}
+
+func TestScanOperatorsLongestMatch(t *testing.T) {
+	s := newScanner(`a>>>=b>>c<=d=>e!=!f&&&g||| h--->i++++j ? k : l;`, nil, 0)
+	expected := []struct {
+		tok token.Token
+		lit string
+	}{
+		{token.IDENT, "a"}, {token.SAR_ASSIGN, ">>>="}, {token.IDENT, "b"}, {token.SHR, ">>"}, {token.IDENT, "c"}, {token.LEQ, "<="},
+		{token.IDENT, "d"}, {token.DARROW, "=>"}, {token.IDENT, "e"}, {token.NEQ, "!="}, {token.NOT, "!"}, {token.IDENT, "f"},
+		{token.LAND, "&&"}, {token.AND, "&"}, {token.IDENT, "g"}, {token.LOR, "||"}, {token.OR, "|"}, {token.IDENT, "h"},
+		{token.DEC, "--"}, {token.ARROW, "->"}, {token.IDENT, "i"}, {token.INC, "++"}, {token.INC, "++"}, {token.IDENT, "j"},
+		{token.QUESTION, "?"}, {token.IDENT, "k"}, {token.COLON, ":"}, {token.IDENT, "l"}, {token.SEMICOLON, ";"},
+	}
+	for _, e := range expected {
+		_, tok, lit := s.Scan()
+		assert.Require(t, lit == e.lit)
+		assert.Require(t, tok == e.tok)
+	}
+}
+
+func TestScanKeywords(t *testing.T) {
+	s := newScanner(`function view returns ether days let switch payable2 _payable uint256 address error;`, nil, 0)
+	expected := []struct {
+		tok token.Token
+		lit string
+	}{
+		{token.FUNCTION, "function"}, {token.VIEW, "view"}, {token.RETURNS, "returns"},
+		{token.ETHER, "ether"}, {token.DAYS, "days"},
+		{token.LET, "let"}, {token.SWITCH, "switch"},
+		{token.IDENT, "payable2"}, {token.IDENT, "_payable"}, {token.IDENT, "uint256"}, {token.IDENT, "address"}, {token.IDENT, "error"},
+		{token.SEMICOLON, ";"},
+	}
+	for _, e := range expected {
+		_, tok, lit := s.Scan()
+		assert.Require(t, lit == e.lit)
+		assert.Require(t, tok == e.tok)
+	}
+	assert.OK(t, token.VIEW.IsKeyword() && !token.VIEW.IsUnit() && !token.VIEW.IsReserved())
+	assert.OK(t, token.ETHER.IsKeyword() && token.ETHER.IsUnit())
+	assert.OK(t, token.LET.IsKeyword() && token.LET.IsReserved())
+	assert.OK(t, !token.IDENT.IsKeyword())
+	assert.OK(t, token.Lookup("keyword_beg") == token.IDENT)
+}
+
+func TestScanNumbers(t *testing.T) {
+	tests := []struct {
+		src string
+		tok token.Token
+	}{
+		{`0`, token.INT}, {`10`, token.INT}, {`1_000_000`, token.INT},
+		{`0xff`, token.INT}, {`0xDEAD_beef`, token.INT},
+		{`2.5`, token.RATIONAL}, {`.5`, token.RATIONAL}, {`1_000.000_1`, token.RATIONAL},
+		{`1e18`, token.RATIONAL}, {`2E10`, token.RATIONAL}, {`2.5e-3`, token.RATIONAL}, {`1_0e1_0`, token.RATIONAL},
+	}
+	for _, tt := range tests {
+		s := newScanner(tt.src+" ether;", nil, 0)
+		_, tok, lit := s.Scan()
+		assert.OK(t, tok == tt.tok, tt.src)
+		assert.OK(t, lit == tt.src, tt.src)
+		_, tok, _ = s.Scan()
+		assert.OK(t, tok == token.ETHER, tt.src)
+	}
+
+	s := newScanner(`1.foo 1ether 1.e5;`, nil, 0)
+	expected := []struct {
+		tok token.Token
+		lit string
+	}{
+		{token.INT, "1"}, {token.PERIOD, "."}, {token.IDENT, "foo"},
+		{token.INT, "1"}, {token.ETHER, "ether"},
+		{token.INT, "1"}, {token.PERIOD, "."}, {token.IDENT, "e5"},
+		{token.SEMICOLON, ";"},
+	}
+	for _, e := range expected {
+		_, tok, lit := s.Scan()
+		assert.Require(t, lit == e.lit)
+		assert.Require(t, tok == e.tok)
+	}
+}
+
+func TestScanNumbers_Errors(t *testing.T) {
+	tests := []struct {
+		src    string
+		tok    token.Token
+		offset int
+		msg    string
+	}{
+		{`0x`, token.INT, 0, "hexadecimal literal has no digits"},
+		{`0x_ff`, token.INT, 2, "'_' must separate successive digits"},
+		{`1__0`, token.INT, 2, "'_' must separate successive digits"},
+		{`1_`, token.INT, 1, "'_' must separate successive digits"},
+		{`1.5_`, token.RATIONAL, 3, "'_' must separate successive digits"},
+		{`1e`, token.RATIONAL, 0, "exponent has no digits"},
+		{`1e-`, token.RATIONAL, 0, "exponent has no digits"},
+		{`2.5E`, token.RATIONAL, 0, "exponent has no digits"},
+		{`1e1_`, token.RATIONAL, 3, "'_' must separate successive digits"},
+	}
+	for _, tt := range tests {
+		var errs []string
+		var offsets []int
+		s := newScanner(tt.src+" ;", func(pos token.Position, msg string) {
+			offsets = append(offsets, pos.Offset)
+			errs = append(errs, msg)
+		}, 0)
+		_, tok, lit := s.Scan()
+		assert.OK(t, tok == tt.tok, tt.src)
+		assert.OK(t, lit == tt.src, tt.src)
+		assert.Require(t, len(errs) == 1, tt.src)
+		assert.OK(t, errs[0] == tt.msg, tt.src)
+		assert.OK(t, offsets[0] == tt.offset, tt.src)
+		_, tok, _ = s.Scan()
+		assert.OK(t, tok == token.SEMICOLON, tt.src)
+	}
+}
+
+func TestScanComments(t *testing.T) {
+	src := `// line
+/// natspec line
+//// not natspec
+/* block */
+/** natspec
+  * block */
+/**/
+/***/
+/*** section ***/
+contract A {} // trailing
+x /* unterminated`
+	expected := []struct {
+		tok token.Token
+		lit string
+	}{
+		{token.COMMENT, "// line"},
+		{token.NATSPEC, "/// natspec line"},
+		{token.COMMENT, "//// not natspec"},
+		{token.COMMENT, "/* block */"},
+		{token.NATSPEC, "/** natspec\n  * block */"},
+		{token.COMMENT, "/**/"},
+		{token.COMMENT, "/***/"},
+		{token.COMMENT, "/*** section ***/"},
+		{token.CONTRACT, "contract"}, {token.IDENT, "A"}, {token.LBRACE, "{"}, {token.RBRACE, "}"},
+		{token.COMMENT, "// trailing"},
+		{token.IDENT, "x"},
+		{token.COMMENT, "/* unterminated"},
+	}
+
+	{
+		s := newScanner(src, nil, ScanComments)
+		for _, e := range expected {
+			_, tok, lit := s.Scan()
+			assert.Require(t, lit == e.lit)
+			assert.Require(t, tok == e.tok)
+		}
+	}
+	{
+		s := newScanner(src, nil, 0)
+		for _, e := range expected {
+			if e.tok == token.COMMENT || e.tok == token.NATSPEC {
+				continue
+			}
+			_, tok, lit := s.Scan()
+			assert.Require(t, lit == e.lit)
+			assert.Require(t, tok == e.tok)
+		}
+	}
+}
+
+func TestScanStrings(t *testing.T) {
+	tests := []struct {
+		src  string
+		tok  token.Token
+		errs int
+	}{
+		{`"abc"`, token.STRING, 0},
+		{`'abc'`, token.STRING, 0},
+		{`"it's"`, token.STRING, 0},
+		{`'say "hi"'`, token.STRING, 0},
+		{`"\n\r\t\\\"\'\x41\u00e9"`, token.STRING, 0},
+		{"\"line\\\ncontinued\"", token.STRING, 0},
+		{`unicode"😃 é"`, token.UNICODE_STRING, 0},
+		{`unicode'é'`, token.UNICODE_STRING, 0},
+		{`hex"00ff"`, token.HEX_STRING, 0},
+		{`hex'00_ff_AB'`, token.HEX_STRING, 0},
+		{`hex""`, token.HEX_STRING, 0},
+		{`"é"`, token.STRING, 1},
+		{`"\q"`, token.STRING, 1},
+		{`"\x4"`, token.STRING, 1},
+		{`"\u12G4"`, token.STRING, 1},
+		{`hex"0ff"`, token.HEX_STRING, 1},
+		{`hex"00__ff"`, token.HEX_STRING, 1},
+		{`hex"_00"`, token.HEX_STRING, 1},
+		{`hex"0g"`, token.HEX_STRING, 1},
+	}
+	for _, tt := range tests {
+		var errs []string
+		s := newScanner(tt.src+";", func(pos token.Position, msg string) {
+			errs = append(errs, msg)
+		}, 0)
+		_, tok, lit := s.Scan()
+		assert.OK(t, tok == tt.tok, tt.src)
+		assert.OK(t, lit == tt.src, tt.src)
+		assert.OK(t, len(errs) == tt.errs, tt.src)
+		assert.OK(t, s.ErrorCount == tt.errs, tt.src)
+		_, tok, _ = s.Scan()
+		assert.OK(t, tok == token.SEMICOLON, tt.src)
+	}
+}
+
+func TestScanUnterminatedString(t *testing.T) {
+	for _, src := range []string{`"abc`, "'abc\n';", `"abc\`, `hex"00`} {
+		var errs []string
+		var positions []token.Position
+		s := newScanner("x "+src, func(pos token.Position, msg string) {
+			positions = append(positions, pos)
+			errs = append(errs, msg)
+		}, 0)
+		s.Scan()
+		_, tok, _ := s.Scan()
+		assert.OK(t, tok == token.STRING || tok == token.HEX_STRING, src)
+		assert.Require(t, len(errs) >= 1, src)
+		assert.OK(t, errs[0] == "string literal not terminated", src)
+		assert.OK(t, positions[0].Offset == 2, src)
+	}
+}
+
+func TestScanEOF(t *testing.T) {
+	for _, src := range []string{``, ` `, "x\n", `x`, `1`, `+`, `>>`, `"a`, `// c`, `/* c`, `hex`} {
+		s := newScanner(src, nil, 0)
+		tok := token.ILLEGAL
+		for i := 0; i < 10 && tok != token.EOF; i++ {
+			_, tok, _ = s.Scan()
+		}
+		assert.OK(t, tok == token.EOF, src)
+		_, tok, lit := s.Scan()
+		assert.OK(t, tok == token.EOF, src)
+		assert.OK(t, lit == "", src)
+	}
+}
+
+func TestPeek(t *testing.T) {
+	errs := 0
+	s := newScanner("a # b", func(pos token.Position, msg string) { errs++ }, 0)
+	assert.OK(t, s.Peek() == token.IDENT)
+	_, tok, _ := s.Scan()
+	assert.OK(t, tok == token.IDENT)
+	assert.OK(t, s.Peek() == token.ILLEGAL)
+	assert.OK(t, s.Peek() == token.ILLEGAL)
+	assert.OK(t, reflect.DeepEqual(s.PeekN(3), []token.Token{token.ILLEGAL, token.IDENT}))
+	assert.OK(t, errs == 0 && s.ErrorCount == 0)
+	_, tok, _ = s.Scan()
+	assert.OK(t, tok == token.ILLEGAL)
+	assert.OK(t, errs == 1)
+	pos, tok, lit := s.Scan()
+	assert.OK(t, tok == token.IDENT && lit == "b")
+	assert.OK(t, pos == 5)
+	assert.OK(t, s.Peek() == token.EOF)
+}
+
+func TestScanErrors(t *testing.T) {
+	var positions []token.Position
+	var msgs []string
+	s := newScanner("a # b\n/* c", func(pos token.Position, msg string) {
+		positions = append(positions, pos)
+		msgs = append(msgs, msg)
+	}, 0)
+
+	_, tok, lit := s.Scan()
+	assert.OK(t, tok == token.IDENT && lit == "a")
+	_, tok, lit = s.Scan()
+	assert.OK(t, tok == token.ILLEGAL && lit == "#")
+	_, tok, lit = s.Scan()
+	assert.OK(t, tok == token.IDENT && lit == "b")
+	_, tok, _ = s.Scan()
+	assert.OK(t, tok == token.EOF)
+
+	assert.Require(t, len(msgs) == 2)
+	assert.OK(t, positions[0].String() == "1:3")
+	assert.OK(t, msgs[0] == "illegal character U+0023 '#'")
+	assert.OK(t, positions[1].String() == "2:1")
+	assert.OK(t, msgs[1] == "comment not terminated")
+	assert.OK(t, s.ErrorCount == 2)
+}
+
+func TestErrorList(t *testing.T) {
+	var list ErrorList
+	assert.OK(t, list.Err() == nil)
+
+	s := newScanner("'a\n# b", list.Add, 0)
+	for {
+		if _, tok, _ := s.Scan(); tok == token.EOF {
+			break
+		}
+	}
+	list.Add(token.Position{Filename: "", Offset: 0, Line: 1, Column: 1}, "first")
+	list.Sort()
+
+	assert.Require(t, list.Len() == 3)
+	assert.OK(t, list[0].Msg == "first")
+	assert.OK(t, list[1].Error() == "1:1: string literal not terminated")
+	assert.OK(t, list[2].Error() == "2:1: illegal character U+0023 '#'")
+	assert.OK(t, list.Err().Error() == "1:1: first (and 2 more errors)")
+
+	list.Reset()
+	assert.OK(t, list.Err() == nil)
+}
@@ -1,59 +1,218 @@
 package token
 
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Position describes an arbitrary source position including the file,
+// line, and column location. Offsets and columns count runes.
+type Position struct {
+	Filename string // filename, if any
+	Offset   int    // offset, starting at 0
+	Line     int    // line number, starting at 1
+	Column   int    // column number, starting at 1 (rune count)
+}
+
+// IsValid reports whether the position is valid.
+func (pos *Position) IsValid() bool { return pos.Line > 0 }
+
+// String returns a string in one of several forms:
+//
+//	file:line:column    valid position with file name
+//	line:column         valid position without file name
+//	file                invalid position with file name
+//	-                   invalid position without file name
+func (pos Position) String() string {
+	s := pos.Filename
+	if pos.IsValid() {
+		if s != "" {
+			s += ":"
+		}
+		s += fmt.Sprintf("%d:%d", pos.Line, pos.Column)
+	}
+	if s == "" {
+		s = "-"
+	}
+	return s
+}
+
+// Pos is a compact encoding of a source position within a file set.
+// It can be converted into a Position for a more convenient, but much
+// larger, representation.
 type Pos int
 
+// NoPos is the zero value for Pos; there is no file and line information
+// associated with it.
+const NoPos Pos = 0
+
+// IsValid reports whether the position is valid.
+func (p Pos) IsValid() bool { return p != NoPos }
+
+// A File is a handle for a file belonging to a FileSet.
+// A File has a name, size, and line offset table.
 type File struct {
+	name string
 	base int
 	size int
 
-	lines [][]int
+	mutex sync.Mutex
+	lines []int // lines contains the offset of the first rune for each line
 }
 
-func NewFile() *File {
-	return &File{lines: [][]int{[]int{}}}
+// Name returns the file name of file f as registered with AddFile.
+func (f *File) Name() string { return f.name }
+
+// Base returns the base offset of file f as registered with AddFile.
+func (f *File) Base() int { return f.base }
+
+// Size returns the size of file f as registered with AddFile.
+func (f *File) Size() int { return f.size }
+
+// LineCount returns the number of lines in file f.
+func (f *File) LineCount() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.lines)
 }
 
+// AddLine adds the line offset for a new line. The line offset must be
+// larger than the offset for the previous line and smaller than the file
+// size; otherwise the line offset is ignored.
 func (f *File) AddLine(offset int) {
-	f.lines = append(f.lines, []int{})
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if i := len(f.lines); (i == 0 || f.lines[i-1] < offset) && offset < f.size {
+		f.lines = append(f.lines, offset)
+	}
 }
 
-func (f *File) AddCharacter(offset int) {
-	lx := len(f.lines) - 1
-	f.lines[lx] = append(f.lines[lx], offset)
+// LineStart returns the Pos value of the start of the line.
+// LineStart panics if the 1-based line number is invalid.
+func (f *File) LineStart(line int) Pos {
+	if line < 1 {
+		panic(fmt.Sprintf("invalid line number %d (should be >= 1)", line))
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if line > len(f.lines) {
+		panic(fmt.Sprintf("invalid line number %d (should be < %d)", line, len(f.lines)+1))
+	}
+	return Pos(f.base + f.lines[line-1])
 }
 
-func (f *File) Line(offset int) int {
-	for l, ld := range f.lines {
-		for _, cd := range ld {
-			if offset == cd {
-				return l + 1
-			}
-		}
+// Pos returns the Pos value for the given file offset;
+// the offset must be <= f.Size().
+func (f *File) Pos(offset int) Pos {
+	if offset < 0 || offset > f.size {
+		panic(fmt.Sprintf("invalid file offset %d (should be <= %d)", offset, f.size))
 	}
-	return 0
+	return Pos(f.base + offset)
 }
 
-func (f *File) Character(offset int) int {
-	for _, ld := range f.lines {
-		for c, cd := range ld {
-			if offset == cd {
-				return c + 1
-			}
-		}
+// Offset returns the offset for the given file position p;
+// p must be a valid Pos value in that file.
+func (f *File) Offset(p Pos) int {
+	if int(p) < f.base || int(p) > f.base+f.size {
+		panic(fmt.Sprintf("invalid Pos value %d (should be in [%d, %d])", p, f.base, f.base+f.size))
 	}
-	return 0
+	return int(p) - f.base
 }
 
-func (f *File) Offset(line, character int) int {
-	for l, ld := range f.lines {
-		if l+1 != line {
-			continue
-		}
-		for c, cd := range ld {
-			if c+1 == character {
-				return cd
-			}
-		}
+// Line returns the line number for the given file position p;
+// p must be a Pos value in that file or NoPos.
+func (f *File) Line(p Pos) int {
+	return f.Position(p).Line
+}
+
+// Position returns the Position value for the given file position p.
+// The line is found by binary search in the line offset table.
+func (f *File) Position(p Pos) (pos Position) {
+	if p == NoPos {
+		return
+	}
+	offset := f.Offset(p)
+	pos.Filename = f.name
+	pos.Offset = offset
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if i := sort.Search(len(f.lines), func(i int) bool { return f.lines[i] > offset }) - 1; i >= 0 {
+		pos.Line = i + 1
+		pos.Column = offset - f.lines[i] + 1
+	}
+	return
+}
+
+// A FileSet represents a set of source files.
+// Methods of file sets are synchronized; multiple goroutines
+// may invoke them concurrently.
+type FileSet struct {
+	mutex sync.RWMutex
+	base  int     // base offset for the next file
+	files []*File // list of files in the order added to the set
+}
+
+// NewFileSet creates a new file set.
+func NewFileSet() *FileSet {
+	return &FileSet{base: 1} // 0 == NoPos
+}
+
+// Base returns the minimum base offset that must be provided to
+// AddFile when adding the next file.
+func (s *FileSet) Base() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.base
+}
+
+// AddFile adds a new file with a given filename, base offset, and file size
+// to the file set s and returns the file. Multiple files may have the same
+// name. The base offset must not be smaller than the FileSet's Base(), and
+// size must not be negative. As a special case, if a negative base is
+// provided, the current value of the FileSet's Base() is used instead.
+//
+// Adding the file will set the file set's Base() value to base + size + 1
+// as the minimum base value for the next file, so that a Pos at the end of
+// a file never belongs to the next one.
+func (s *FileSet) AddFile(filename string, base, size int) *File {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if base < 0 {
+		base = s.base
+	}
+	if base < s.base {
+		panic(fmt.Sprintf("invalid base %d (should be >= %d)", base, s.base))
+	}
+	if size < 0 {
+		panic(fmt.Sprintf("invalid size %d (should be >= 0)", size))
+	}
+	f := &File{name: filename, base: base, size: size, lines: []int{0}}
+	s.base = base + size + 1
+	s.files = append(s.files, f)
+	return f
+}
+
+// File returns the file that contains the position p.
+// If no such file is found, the result is nil.
+func (s *FileSet) File(p Pos) *File {
+	if p == NoPos {
+		return nil
+	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	i := sort.Search(len(s.files), func(i int) bool { return s.files[i].base > int(p) }) - 1
+	if i >= 0 && int(p) <= s.files[i].base+s.files[i].size {
+		return s.files[i]
+	}
+	return nil
+}
+
+// Position converts a Pos p in the fileset into a Position value.
+func (s *FileSet) Position(p Pos) (pos Position) {
+	if f := s.File(p); f != nil {
+		return f.Position(p)
 	}
-	return 0
+	return
 }
@@ -0,0 +1,77 @@
+package token
+
+import (
+	"testing"
+
+	"github.com/ToQoz/gopwt/assert"
+)
+
+func TestFilePosition(t *testing.T) {
+	src := []rune("ab\ncdé\n\nf")
+	f := NewFileSet().AddFile("a.sol", -1, len(src))
+	for i, ch := range src {
+		if ch == '\n' {
+			f.AddLine(i + 1)
+		}
+	}
+	assert.Require(t, f.LineCount() == 4)
+
+	tests := []struct {
+		offset int
+		line   int
+		column int
+	}{
+		{0, 1, 1}, {1, 1, 2}, {2, 1, 3},
+		{3, 2, 1}, {5, 2, 3}, {6, 2, 4},
+		{7, 3, 1},
+		{8, 4, 1}, {9, 4, 2},
+	}
+	for _, tt := range tests {
+		pos := f.Position(f.Pos(tt.offset))
+		assert.OK(t, pos.Filename == "a.sol")
+		assert.OK(t, pos.Offset == tt.offset)
+		assert.OK(t, pos.Line == tt.line)
+		assert.OK(t, pos.Column == tt.column)
+		assert.OK(t, f.Offset(f.Pos(tt.offset)) == tt.offset)
+	}
+	assert.OK(t, f.LineStart(2) == f.Pos(3))
+	assert.OK(t, f.LineStart(4) == f.Pos(8))
+	assert.OK(t, f.Position(f.Pos(5)).String() == "a.sol:2:3")
+	nopos := f.Position(NoPos)
+	assert.OK(t, nopos.String() == "-")
+	assert.OK(t, !nopos.IsValid())
+}
+
+func TestFileAddLineIgnoresInvalidOffsets(t *testing.T) {
+	f := NewFileSet().AddFile("", -1, 10)
+	f.AddLine(5)
+	f.AddLine(5)
+	f.AddLine(3)
+	f.AddLine(10)
+	assert.OK(t, f.LineCount() == 2)
+}
+
+func TestFileSetMultipleFiles(t *testing.T) {
+	fset := NewFileSet()
+	a := fset.AddFile("a.sol", -1, 10)
+	b := fset.AddFile("b.sol", -1, 5)
+	c := fset.AddFile("c.sol", fset.Base()+100, 0)
+	b.AddLine(2)
+
+	assert.OK(t, a.Base() == 1)
+	assert.OK(t, b.Base() == a.Base()+a.Size()+1)
+	assert.OK(t, fset.File(a.Pos(0)) == a)
+	assert.OK(t, fset.File(a.Pos(10)) == a)
+	assert.OK(t, fset.File(b.Pos(0)) == b)
+	assert.OK(t, fset.File(b.Pos(5)) == b)
+	assert.OK(t, fset.File(c.Pos(0)) == c)
+	assert.OK(t, fset.File(Pos(b.Base()+50)) == nil)
+	assert.OK(t, fset.File(NoPos) == nil)
+
+	pos := fset.Position(b.Pos(3))
+	assert.OK(t, pos.Filename == "b.sol")
+	assert.OK(t, pos.Offset == 3)
+	assert.OK(t, pos.Line == 2)
+	assert.OK(t, pos.Column == 2)
+	assert.OK(t, pos.String() == "b.sol:2:2")
+}
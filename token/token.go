@@ -1,13 +1,21 @@
 package token
 
+import "strings"
+
 type Token int
 
 const (
 	ILLEGAL Token = iota
+	EOF
+	COMMENT // comment
+	NATSPEC // NatSpec doc comment
 
-	IDENT  // contract
-	INT    // 10
-	STRING // "string"
+	IDENT          // contract
+	INT            // 10
+	RATIONAL       // 2.5
+	STRING         // "string"
+	HEX_STRING     // hex"00ff"
+	UNICODE_STRING // unicode"😃"
 
 	ADD // +
 	SUB // -
@@ -16,15 +24,44 @@ const (
 	QUO // /
 	REM // %
 
-	AND     // &
-	OR      // |
-	XOR     // ^
-	SHL     // <<
-	SHR     // >>
-	AND_NOT // &^
+	AND // &
+	OR  // |
+	XOR // ^
+	SHL // <<
+	SHR // >>
+	SAR // >>>
+
+	ADD_ASSIGN // +=
+	SUB_ASSIGN // -=
+	MUL_ASSIGN // *=
+	QUO_ASSIGN // /=
+	REM_ASSIGN // %=
+
+	AND_ASSIGN // &=
+	OR_ASSIGN  // |=
+	XOR_ASSIGN // ^=
+	SHL_ASSIGN // <<=
+	SHR_ASSIGN // >>=
+	SAR_ASSIGN // >>>=
+
+	LAND // &&
+	LOR  // ||
+	INC  // ++
+	DEC  // --
 
 	ASSIGN // =
 	EQ     // ==
+	NEQ    // !=
+	LSS    // <
+	GTR    // >
+	LEQ    // <=
+	GEQ    // >=
+	NOT    // !
+	TILDE  // ~
+
+	DARROW   // =>
+	ARROW    // ->
+	QUESTION // ?
 
 	LPAREN // (
 	LBRACK // [
@@ -37,4 +74,183 @@ const (
 	RBRACE    // }
 	SEMICOLON // ;
 	COLON     // :
+
+	keyword_beg
+	// Keywords
+	ABSTRACT    // abstract
+	ANONYMOUS   // anonymous
+	AS          // as
+	ASSEMBLY    // assembly
+	BREAK       // break
+	CALLDATA    // calldata
+	CATCH       // catch
+	CONSTANT    // constant
+	CONSTRUCTOR // constructor
+	CONTINUE    // continue
+	CONTRACT    // contract
+	DELETE      // delete
+	DO          // do
+	ELSE        // else
+	EMIT        // emit
+	ENUM        // enum
+	EVENT       // event
+	EXTERNAL    // external
+	FALSE       // false
+	FOR         // for
+	FUNCTION    // function
+	IF          // if
+	IMMUTABLE   // immutable
+	IMPORT      // import
+	INDEXED     // indexed
+	INTERFACE   // interface
+	INTERNAL    // internal
+	IS          // is
+	LIBRARY     // library
+	MAPPING     // mapping
+	MEMORY      // memory
+	MODIFIER    // modifier
+	NEW         // new
+	OVERRIDE    // override
+	PAYABLE     // payable
+	PRAGMA      // pragma
+	PRIVATE     // private
+	PUBLIC      // public
+	PURE        // pure
+	RETURN      // return
+	RETURNS     // returns
+	STORAGE     // storage
+	STRUCT      // struct
+	TRUE        // true
+	TRY         // try
+	TYPE        // type
+	UNCHECKED   // unchecked
+	USING       // using
+	VIEW        // view
+	VIRTUAL     // virtual
+	WHILE       // while
+	keyword_end
+
+	unit_beg
+	// Denominations for number literals
+	WEI     // wei
+	GWEI    // gwei
+	ETHER   // ether
+	SECONDS // seconds
+	MINUTES // minutes
+	HOURS   // hours
+	DAYS    // days
+	WEEKS   // weeks
+	unit_end
+
+	reserved_beg
+	// Reserved keywords
+	AFTER       // after
+	ALIAS       // alias
+	APPLY       // apply
+	AUTO        // auto
+	BYTE        // byte
+	CASE        // case
+	COPYOF      // copyof
+	DEFAULT     // default
+	DEFINE      // define
+	FINAL       // final
+	IMPLEMENTS  // implements
+	IN          // in
+	INLINE      // inline
+	LET         // let
+	MACRO       // macro
+	MATCH       // match
+	MUTABLE     // mutable
+	NULL        // null
+	OF          // of
+	PARTIAL     // partial
+	PROMISE     // promise
+	REFERENCE   // reference
+	RELOCATABLE // relocatable
+	SEALED      // sealed
+	SIZEOF      // sizeof
+	STATIC      // static
+	SUPPORTS    // supports
+	SWITCH      // switch
+	TYPEDEF     // typedef
+	TYPEOF      // typeof
+	VAR         // var
+	reserved_end
 )
+
+var keywords map[string]Token
+
+func init() {
+	keywords = make(map[string]Token)
+	for _, r := range [][2]Token{{keyword_beg, keyword_end}, {unit_beg, unit_end}, {reserved_beg, reserved_end}} {
+		for tok := r[0] + 1; tok < r[1]; tok++ {
+			keywords[strings.ToLower(tok.String())] = tok
+		}
+	}
+}
+
+// Lookup maps an identifier to its keyword token or IDENT (if not a keyword).
+func Lookup(ident string) Token {
+	if tok, isKeyword := keywords[ident]; isKeyword {
+		return tok
+	}
+	return IDENT
+}
+
+// IsKeyword returns true for keywords, denominations and reserved keywords.
+func (tok Token) IsKeyword() bool {
+	return keyword_beg < tok && tok < keyword_end || tok.IsUnit() || tok.IsReserved()
+}
+
+// IsUnit returns true for the denominations of number literals, like ether or days.
+func (tok Token) IsUnit() bool { return unit_beg < tok && tok < unit_end }
+
+// IsReserved returns true for keywords reserved for future use.
+func (tok Token) IsReserved() bool { return reserved_beg < tok && tok < reserved_end }
+
+// A set of constants for precedence-based expression parsing.
+// Non-operators have lowest precedence, followed by operators
+// starting with precedence 1 up to unary operators. The highest
+// precedence serves as "catch-all" precedence for selector,
+// indexing, and other operator and delimiter tokens.
+const (
+	LowestPrec  = 0 // non-operators
+	UnaryPrec   = 12
+	HighestPrec = 13
+)
+
+// Precedence returns the operator precedence of the binary
+// operator op. If op is not a binary operator, the result
+// is LowestPrec.
+func (op Token) Precedence() int {
+	switch op {
+	case LOR:
+		return 1
+	case LAND:
+		return 2
+	case EQ, NEQ:
+		return 3
+	case LSS, GTR, LEQ, GEQ:
+		return 4
+	case OR:
+		return 5
+	case XOR:
+		return 6
+	case AND:
+		return 7
+	case SHL, SHR, SAR:
+		return 8
+	case ADD, SUB:
+		return 9
+	case MUL, QUO, REM:
+		return 10
+	case POW:
+		return 11
+	}
+	return LowestPrec
+}
+
+// IsAssignOp returns true for `=` and the compound assignment operators.
+func (op Token) IsAssignOp() bool {
+	return op == ASSIGN || ADD_ASSIGN <= op && op <= SAR_ASSIGN
+}
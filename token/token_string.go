@@ -4,9 +4,9 @@ package token
 
 import "strconv"
 
-const _Token_name = "ILLEGALIDENTINTSTRINGADDSUBMULPOWQUOREMANDORXORSHLSHRAND_NOTASSIGNEQLPARENLBRACKLBRACECOMMAPERIODRPARENRBRACKRBRACESEMICOLONCOLON"
+const _Token_name = "ILLEGALEOFCOMMENTNATSPECIDENTINTRATIONALSTRINGHEX_STRINGUNICODE_STRINGADDSUBMULPOWQUOREMANDORXORSHLSHRSARADD_ASSIGNSUB_ASSIGNMUL_ASSIGNQUO_ASSIGNREM_ASSIGNAND_ASSIGNOR_ASSIGNXOR_ASSIGNSHL_ASSIGNSHR_ASSIGNSAR_ASSIGNLANDLORINCDECASSIGNEQNEQLSSGTRLEQGEQNOTTILDEDARROWARROWQUESTIONLPARENLBRACKLBRACECOMMAPERIODRPARENRBRACKRBRACESEMICOLONCOLONkeyword_begABSTRACTANONYMOUSASASSEMBLYBREAKCALLDATACATCHCONSTANTCONSTRUCTORCONTINUECONTRACTDELETEDOELSEEMITENUMEVENTEXTERNALFALSEFORFUNCTIONIFIMMUTABLEIMPORTINDEXEDINTERFACEINTERNALISLIBRARYMAPPINGMEMORYMODIFIERNEWOVERRIDEPAYABLEPRAGMAPRIVATEPUBLICPURERETURNRETURNSSTORAGESTRUCTTRUETRYTYPEUNCHECKEDUSINGVIEWVIRTUALWHILEkeyword_endunit_begWEIGWEIETHERSECONDSMINUTESHOURSDAYSWEEKSunit_endreserved_begAFTERALIASAPPLYAUTOBYTECASECOPYOFDEFAULTDEFINEFINALIMPLEMENTSININLINELETMACROMATCHMUTABLENULLOFPARTIALPROMISEREFERENCERELOCATABLESEALEDSIZEOFSTATICSUPPORTSSWITCHTYPEDEFTYPEOFVARreserved_end"
 
-var _Token_index = [...]uint8{0, 7, 12, 15, 21, 24, 27, 30, 33, 36, 39, 42, 44, 47, 50, 53, 60, 66, 68, 74, 80, 86, 91, 97, 103, 109, 115, 124, 129}
+var _Token_index = [...]uint16{0, 7, 10, 17, 24, 29, 32, 40, 46, 56, 70, 73, 76, 79, 82, 85, 88, 91, 93, 96, 99, 102, 105, 115, 125, 135, 145, 155, 165, 174, 184, 194, 204, 214, 218, 221, 224, 227, 233, 235, 238, 241, 244, 247, 250, 253, 258, 264, 269, 277, 283, 289, 295, 300, 306, 312, 318, 324, 333, 338, 349, 357, 366, 368, 376, 381, 389, 394, 402, 413, 421, 429, 435, 437, 441, 445, 449, 454, 462, 467, 470, 478, 480, 489, 495, 502, 511, 519, 521, 528, 535, 541, 549, 552, 560, 567, 573, 580, 586, 590, 596, 603, 610, 616, 620, 623, 627, 636, 641, 645, 652, 657, 668, 676, 679, 683, 688, 695, 702, 707, 711, 716, 724, 736, 741, 746, 751, 755, 759, 763, 769, 776, 782, 787, 797, 799, 805, 808, 813, 818, 825, 829, 831, 838, 845, 854, 865, 871, 877, 883, 891, 897, 904, 910, 913, 925}
 
 func (i Token) String() string {
 	if i < 0 || i >= Token(len(_Token_index)-1) {
@@ -0,0 +1,126 @@
+package yul
+
+import "github.com/blockchain-labs-org/solzaemon/token"
+
+// Node is implemented by all Yul nodes. A node spans the source from Pos
+// up to, but not including, End.
+type Node interface {
+	Pos() token.Pos
+	End() token.Pos
+}
+
+// Stmt is implemented by all statement nodes.
+type Stmt interface {
+	Node
+}
+
+// Expr is implemented by all expression nodes.
+type Expr interface {
+	Node
+}
+
+// Block is a braced statement list.
+type Block struct {
+	Lbrace token.Pos
+	List   []Stmt
+	Rbrace token.Pos
+}
+
+// VariableDeclaration is `let x, y := f()`.
+type VariableDeclaration struct {
+	Let   token.Pos
+	Names []*Ident
+	Value Expr // or nil
+}
+
+// Assignment is `x, y := f()`.
+type Assignment struct {
+	Lhs    []Expr // *Ident or *MemberAccess
+	Assign token.Pos
+	Value  Expr
+}
+
+// ExprStmt is a function call used as a statement.
+type ExprStmt struct {
+	X Expr
+}
+
+type If struct {
+	If   token.Pos
+	Cond Expr
+	Body *Block
+}
+
+type Switch struct {
+	Switch token.Pos
+	X      Expr
+	Cases  []*Case
+}
+
+// Case is `case Value { ... }`, or `default { ... }` if Value is nil.
+type Case struct {
+	Case  token.Pos
+	Value *Literal // or nil for default
+	Body  *Block
+}
+
+// For is `for { Init } Cond { Post } { Body }`.
+type For struct {
+	For  token.Pos
+	Init *Block
+	Cond Expr
+	Post *Block
+	Body *Block
+}
+
+// BranchStmt is `break`, `continue` or `leave`.
+type BranchStmt struct {
+	TokPos token.Pos
+	Tok    Token // BREAK, CONTINUE or LEAVE
+}
+
+// FunctionDefinition is `function f(a, b) -> r { ... }`.
+type FunctionDefinition struct {
+	Function token.Pos
+	Name     *Ident
+	Params   []*Ident
+	Returns  []*Ident
+	Body     *Block
+}
+
+// BadStmt is a placeholder for a statement containing syntax errors.
+type BadStmt struct {
+	From, To token.Pos
+}
+
+// BadExpr is a placeholder for an expression containing syntax errors.
+type BadExpr struct {
+	From, To token.Pos
+}
+
+type Ident struct {
+	Name    string
+	NamePos token.Pos
+}
+
+// MemberAccess is a suffix of a Solidity variable or function like
+// `x.slot`, `x.offset`, `x.length`, `f.selector` or `f.address`.
+type MemberAccess struct {
+	X      *Ident
+	Member *Ident
+}
+
+// FunctionCall is a call of a builtin or user-defined function.
+type FunctionCall struct {
+	Fun    *Ident
+	Lparen token.Pos
+	Args   []Expr
+	Rparen token.Pos
+}
+
+// Literal is a number, string, hex string or boolean literal.
+type Literal struct {
+	Kind     Token // NUMBER, STRING, HEX_STRING, TRUE or FALSE
+	Value    string
+	ValuePos token.Pos
+}
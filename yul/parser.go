@@ -0,0 +1,288 @@
+package yul
+
+import (
+	"strings"
+
+	"github.com/blockchain-labs-org/solzaemon/scanner"
+	"github.com/blockchain-labs-org/solzaemon/token"
+)
+
+// ParseBlock parses the Yul block of an inline assembly statement, which
+// starts at the rune offset in src, the contents of f. It returns the block
+// and the position just after it. Syntax errors are reported to err.
+//
+// Nothing after the closing '}' of the block is scanned, so that a caller
+// can continue scanning Solidity code there.
+func ParseBlock(f *token.File, src []rune, offset int, err scanner.ErrorHandler) (*Block, token.Pos) {
+	p := &parser{file: f, err: err}
+	p.scanner = NewScanner(f, src, offset, err)
+	p.next()
+	block := &Block{}
+	block.Lbrace = p.expect(LBRACE)
+	block.List = p.parseStmtList()
+	block.Rbrace = p.offset
+	if p.tok != RBRACE {
+		p.errorExpected(p.offset, "'}'")
+		return block, p.offset
+	}
+	return block, p.offset + 1
+}
+
+type parser struct {
+	file     *token.File
+	err      scanner.ErrorHandler
+	lastLine int // line of the last error
+	scanner  *Scanner
+	offset   token.Pos
+	tok      Token
+	lit      string
+}
+
+func (p *parser) next() {
+	p.offset, p.tok, p.lit = p.scanner.Scan()
+}
+
+func (p *parser) error(pos token.Pos, msg string) {
+	epos := p.file.Position(pos)
+	// Report only the first error on a line; the rest are usually
+	// follow-up errors of the first one.
+	if epos.Line == p.lastLine {
+		return
+	}
+	p.lastLine = epos.Line
+	if p.err != nil {
+		p.err(epos, msg)
+	}
+}
+
+func (p *parser) errorExpected(pos token.Pos, msg string) {
+	msg = "expected " + msg
+	if pos == p.offset {
+		switch {
+		case p.tok == EOF:
+			msg += ", found EOF"
+		default:
+			msg += ", found '" + p.lit + "'"
+		}
+	}
+	p.error(pos, msg)
+}
+
+// expect reports an error unless the current token is tok, and consumes
+// the current token if it matches. It returns the position of the token.
+func (p *parser) expect(tok Token) token.Pos {
+	pos := p.offset
+	if p.tok != tok {
+		p.errorExpected(pos, "'"+tok.String()+"'")
+		return pos
+	}
+	p.next()
+	return pos
+}
+
+// ----------------------------------------------------------------------------
+// Statements
+
+func (p *parser) parseBlock() *Block {
+	block := &Block{}
+	block.Lbrace = p.expect(LBRACE)
+	block.List = p.parseStmtList()
+	block.Rbrace = p.expect(RBRACE)
+	return block
+}
+
+func (p *parser) parseStmtList() []Stmt {
+	var list []Stmt
+	for p.tok != RBRACE && p.tok != EOF {
+		list = append(list, p.parseStmt())
+	}
+	return list
+}
+
+func (p *parser) parseStmt() Stmt {
+	switch p.tok {
+	case LBRACE:
+		return p.parseBlock()
+	case LET:
+		decl := &VariableDeclaration{Let: p.offset}
+		p.next()
+		decl.Names = p.parseIdentList()
+		if p.tok == ASSIGN {
+			p.next()
+			decl.Value = p.parseExpr()
+		}
+		return decl
+	case FUNCTION:
+		return p.parseFunctionDefinition()
+	case IF:
+		stmt := &If{If: p.offset}
+		p.next()
+		stmt.Cond = p.parseExpr()
+		stmt.Body = p.parseBlock()
+		return stmt
+	case SWITCH:
+		return p.parseSwitch()
+	case FOR:
+		stmt := &For{For: p.offset}
+		p.next()
+		stmt.Init = p.parseBlock()
+		stmt.Cond = p.parseExpr()
+		stmt.Post = p.parseBlock()
+		stmt.Body = p.parseBlock()
+		return stmt
+	case BREAK, CONTINUE, LEAVE:
+		stmt := &BranchStmt{TokPos: p.offset, Tok: p.tok}
+		p.next()
+		return stmt
+	case IDENT:
+		from := p.offset
+		x := p.parsePath()
+		switch p.tok {
+		case LPAREN:
+			return &ExprStmt{X: p.parseCall(x)}
+		case COMMA, ASSIGN:
+			stmt := &Assignment{Lhs: []Expr{x}}
+			for p.tok == COMMA {
+				p.next()
+				stmt.Lhs = append(stmt.Lhs, p.parsePath())
+			}
+			stmt.Assign = p.expect(ASSIGN)
+			stmt.Value = p.parseExpr()
+			return stmt
+		}
+		p.errorExpected(p.offset, "'(' or ':='")
+		return &BadStmt{From: from, To: p.offset}
+	}
+	pos := p.offset
+	p.errorExpected(pos, "statement")
+	p.next()
+	return &BadStmt{From: pos, To: p.offset}
+}
+
+func (p *parser) parseFunctionDefinition() *FunctionDefinition {
+	def := &FunctionDefinition{Function: p.expect(FUNCTION)}
+	def.Name = p.parseIdent()
+	p.expect(LPAREN)
+	if p.tok != RPAREN {
+		def.Params = p.parseIdentList()
+	}
+	p.expect(RPAREN)
+	if p.tok == ARROW {
+		p.next()
+		def.Returns = p.parseIdentList()
+	}
+	def.Body = p.parseBlock()
+	return def
+}
+
+func (p *parser) parseSwitch() *Switch {
+	stmt := &Switch{Switch: p.expect(SWITCH)}
+	stmt.X = p.parseExpr()
+	for p.tok == CASE {
+		c := &Case{Case: p.offset}
+		p.next()
+		if lit, ok := p.parseExpr().(*Literal); ok {
+			c.Value = lit
+		} else {
+			p.error(c.Case, "expected literal after 'case'")
+		}
+		c.Body = p.parseBlock()
+		stmt.Cases = append(stmt.Cases, c)
+	}
+	if p.tok == DEFAULT {
+		c := &Case{Case: p.offset}
+		p.next()
+		c.Body = p.parseBlock()
+		stmt.Cases = append(stmt.Cases, c)
+	}
+	if len(stmt.Cases) == 0 {
+		p.errorExpected(p.offset, "'case' or 'default'")
+	}
+	return stmt
+}
+
+// ----------------------------------------------------------------------------
+// Expressions
+
+func (p *parser) parseExpr() Expr {
+	switch p.tok {
+	case IDENT:
+		x := p.parsePath()
+		if p.tok == LPAREN {
+			return p.parseCall(x)
+		}
+		return x
+	case NUMBER, STRING, HEX_STRING, TRUE, FALSE:
+		lit := &Literal{Kind: p.tok, Value: p.lit, ValuePos: p.offset}
+		p.next()
+		return lit
+	}
+	pos := p.offset
+	p.errorExpected(pos, "expression")
+	return &BadExpr{From: pos, To: pos}
+}
+
+// parseCall parses the arguments of a call of fun.
+func (p *parser) parseCall(fun Expr) Expr {
+	call := &FunctionCall{}
+	if id, ok := fun.(*Ident); ok {
+		call.Fun = id
+	} else {
+		p.error(p.offset, "expected function name")
+		call.Fun = &Ident{Name: "_", NamePos: p.offset}
+	}
+	call.Lparen = p.expect(LPAREN)
+	for p.tok != RPAREN && p.tok != EOF {
+		call.Args = append(call.Args, p.parseExpr())
+		if p.tok != COMMA {
+			break
+		}
+		p.next()
+	}
+	call.Rparen = p.expect(RPAREN)
+	return call
+}
+
+// members are the suffixes accessing properties of Solidity variables and
+// functions.
+var members = map[string]bool{
+	"slot":     true,
+	"offset":   true,
+	"length":   true,
+	"selector": true,
+	"address":  true,
+}
+
+// parsePath parses an identifier, or a member access like `x.slot`.
+func (p *parser) parsePath() Expr {
+	id := p.parseIdent()
+	if i := strings.LastIndexByte(id.Name, '.'); i > 0 && members[id.Name[i+1:]] {
+		// positions count runes; identifiers are ASCII
+		return &MemberAccess{
+			X:      &Ident{Name: id.Name[:i], NamePos: id.NamePos},
+			Member: &Ident{Name: id.Name[i+1:], NamePos: id.NamePos + token.Pos(i+1)},
+		}
+	}
+	return id
+}
+
+func (p *parser) parseIdent() *Ident {
+	pos := p.offset
+	name := "_"
+	if p.tok == IDENT {
+		name = p.lit
+		p.next()
+	} else {
+		p.errorExpected(pos, "identifier")
+	}
+	return &Ident{Name: name, NamePos: pos}
+}
+
+func (p *parser) parseIdentList() []*Ident {
+	list := []*Ident{p.parseIdent()}
+	for p.tok == COMMA {
+		p.next()
+		list = append(list, p.parseIdent())
+	}
+	return list
+}
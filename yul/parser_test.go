@@ -0,0 +1,143 @@
+package yul
+
+import (
+	"testing"
+
+	"github.com/ToQoz/gopwt/assert"
+	"github.com/blockchain-labs-org/solzaemon/scanner"
+	"github.com/blockchain-labs-org/solzaemon/token"
+)
+
+func parse(src string) (*token.File, *Block, token.Pos, scanner.ErrorList) {
+	runes := []rune(src)
+	f := token.NewFileSet().AddFile("", -1, len(runes))
+	var errs scanner.ErrorList
+	block, end := ParseBlock(f, runes, 0, errs.Add)
+	return f, block, end, errs
+}
+
+func TestParseBlock(t *testing.T) {
+	f, block, end, errs := parse(`{
+	let ptr := mload(0x40)
+	let a, b
+	a, b := f(x.slot, ptr)
+	sstore(s.slot, add(a, 1))
+	if iszero(b) { revert(0, 0) }
+	switch a
+	case 0 { b := true }
+	case "x" { leave }
+	default { }
+	for { let i := 0 } lt(i, 10) { i := add(i, 1) } { break continue }
+	function f(p, q) -> r, s { r := p.offset }
+	{}
+} x;`)
+	assert.Require(t, len(errs) == 0)
+	assert.OK(t, f.Position(end).Line == 14 && f.Position(end).Column == 2)
+	assert.Require(t, len(block.List) == 9)
+
+	ptr := block.List[0].(*VariableDeclaration)
+	assert.OK(t, ptr.Names[0].Name == "ptr")
+	call := ptr.Value.(*FunctionCall)
+	assert.OK(t, call.Fun.Name == "mload")
+	assert.OK(t, call.Args[0].(*Literal).Kind == NUMBER)
+	assert.OK(t, call.Args[0].(*Literal).Value == "0x40")
+
+	ab := block.List[1].(*VariableDeclaration)
+	assert.OK(t, len(ab.Names) == 2 && ab.Value == nil)
+
+	assign := block.List[2].(*Assignment)
+	assert.OK(t, len(assign.Lhs) == 2)
+	args := assign.Value.(*FunctionCall).Args
+	slot := args[0].(*MemberAccess)
+	assert.OK(t, slot.X.Name == "x" && slot.Member.Name == "slot")
+	assert.OK(t, f.Position(slot.Member.NamePos).Column == len(`	a, b := f(x.s`))
+	assert.OK(t, args[1].(*Ident).Name == "ptr")
+
+	sstore := block.List[3].(*ExprStmt).X.(*FunctionCall)
+	assert.OK(t, sstore.Fun.Name == "sstore")
+	assert.OK(t, sstore.Args[1].(*FunctionCall).Fun.Name == "add")
+
+	ifStmt := block.List[4].(*If)
+	assert.OK(t, ifStmt.Cond.(*FunctionCall).Fun.Name == "iszero")
+	assert.OK(t, len(ifStmt.Body.List) == 1)
+
+	switchStmt := block.List[5].(*Switch)
+	assert.OK(t, switchStmt.X.(*Ident).Name == "a")
+	assert.Require(t, len(switchStmt.Cases) == 3)
+	assert.OK(t, switchStmt.Cases[0].Value.Value == "0")
+	assert.OK(t, switchStmt.Cases[0].Body.List[0].(*Assignment).Value.(*Literal).Kind == TRUE)
+	assert.OK(t, switchStmt.Cases[1].Value.Kind == STRING)
+	assert.OK(t, switchStmt.Cases[1].Body.List[0].(*BranchStmt).Tok == LEAVE)
+	assert.OK(t, switchStmt.Cases[2].Value == nil)
+
+	forStmt := block.List[6].(*For)
+	assert.OK(t, forStmt.Init.List[0].(*VariableDeclaration).Names[0].Name == "i")
+	assert.OK(t, forStmt.Cond.(*FunctionCall).Fun.Name == "lt")
+	assert.OK(t, len(forStmt.Post.List) == 1)
+	assert.OK(t, forStmt.Body.List[0].(*BranchStmt).Tok == BREAK)
+	assert.OK(t, forStmt.Body.List[1].(*BranchStmt).Tok == CONTINUE)
+
+	fn := block.List[7].(*FunctionDefinition)
+	assert.OK(t, fn.Name.Name == "f")
+	assert.OK(t, len(fn.Params) == 2 && len(fn.Returns) == 2)
+	assert.OK(t, fn.Body.List[0].(*Assignment).Value.(*MemberAccess).Member.Name == "offset")
+
+	assert.OK(t, len(block.List[8].(*Block).List) == 0)
+}
+
+func TestParseBlock_Errors(t *testing.T) {
+	_, block, end, errs := parse(`{
+	let := 1
+	mstore(0, 1
+	x
+	y := 2
+	switch y
+	{}
+	a.b.slot(1)
+	123
+	let ok := 1
+`)
+	assert.Require(t, len(errs) == 7)
+	assert.OK(t, errs[0].Error() == "2:6: expected identifier, found ':='")
+	assert.OK(t, errs[1].Error() == "4:2: expected ')', found 'x'")
+	assert.OK(t, errs[2].Error() == "5:2: expected '(' or ':=', found 'y'")
+	assert.OK(t, errs[3].Error() == "7:2: expected 'case' or 'default', found '{'")
+	assert.OK(t, errs[4].Error() == "8:10: expected function name")
+	assert.OK(t, errs[5].Error() == "9:2: expected statement, found '123'")
+	assert.OK(t, errs[6].Error() == "10:14: expected '}', found EOF")
+	assert.OK(t, block.List[len(block.List)-1].(*VariableDeclaration).Names[0].Name == "ok")
+	assert.OK(t, end == block.Rbrace)
+}
+
+func TestLookupBuiltin(t *testing.T) {
+	assert.OK(t, LookupBuiltin("mstore").Args == 2)
+	assert.OK(t, LookupBuiltin("call").Args == 7)
+	assert.OK(t, LookupBuiltin("sload").Returns == 1)
+	assert.OK(t, LookupBuiltin("mstore2") == nil)
+}
+
+func TestParseBlock_Ranges(t *testing.T) {
+	src := `{
+	let a, b
+	x.slot := add(a, "é")
+	switch a case 0 { leave } default {}
+	function f() -> r { r := 0x1 }
+}`
+	f, block, _, errs := parse(src)
+	assert.Require(t, len(errs) == 0)
+	runes := []rune(src)
+	text := func(n Node) string {
+		return string(runes[f.Offset(n.Pos()):f.Offset(n.End())])
+	}
+
+	assert.OK(t, text(block) == src)
+	assert.OK(t, text(block.List[0]) == "let a, b")
+	assert.OK(t, text(block.List[1]) == `x.slot := add(a, "é")`)
+	assert.OK(t, text(block.List[1].(*Assignment).Lhs[0]) == "x.slot")
+	switchStmt := block.List[2].(*Switch)
+	assert.OK(t, text(switchStmt) == "switch a case 0 { leave } default {}")
+	assert.OK(t, text(switchStmt.Cases[0]) == "case 0 { leave }")
+	assert.OK(t, text(switchStmt.Cases[0].Body.List[0]) == "leave")
+	assert.OK(t, text(switchStmt.Cases[1]) == "default {}")
+	assert.OK(t, text(block.List[3]) == "function f() -> r { r := 0x1 }")
+}
@@ -0,0 +1,70 @@
+package yul
+
+import (
+	"unicode/utf8"
+
+	"github.com/blockchain-labs-org/solzaemon/token"
+)
+
+// textEnd returns the position just after text starting at pos.
+func textEnd(pos token.Pos, text string) token.Pos {
+	return pos + token.Pos(utf8.RuneCountInString(text))
+}
+
+func (s *Block) Pos() token.Pos { return s.Lbrace }
+func (s *Block) End() token.Pos { return s.Rbrace + 1 }
+
+func (s *VariableDeclaration) Pos() token.Pos { return s.Let }
+func (s *VariableDeclaration) End() token.Pos {
+	if s.Value != nil {
+		return s.Value.End()
+	}
+	return s.Names[len(s.Names)-1].End()
+}
+
+func (s *Assignment) Pos() token.Pos { return s.Lhs[0].Pos() }
+func (s *Assignment) End() token.Pos { return s.Value.End() }
+
+func (s *ExprStmt) Pos() token.Pos { return s.X.Pos() }
+func (s *ExprStmt) End() token.Pos { return s.X.End() }
+
+func (s *If) Pos() token.Pos { return s.If }
+func (s *If) End() token.Pos { return s.Body.End() }
+
+func (s *Switch) Pos() token.Pos { return s.Switch }
+func (s *Switch) End() token.Pos {
+	if n := len(s.Cases); n > 0 {
+		return s.Cases[n-1].End()
+	}
+	return s.X.End()
+}
+
+func (s *Case) Pos() token.Pos { return s.Case }
+func (s *Case) End() token.Pos { return s.Body.End() }
+
+func (s *For) Pos() token.Pos { return s.For }
+func (s *For) End() token.Pos { return s.Body.End() }
+
+func (s *BranchStmt) Pos() token.Pos { return s.TokPos }
+func (s *BranchStmt) End() token.Pos { return textEnd(s.TokPos, s.Tok.String()) }
+
+func (s *FunctionDefinition) Pos() token.Pos { return s.Function }
+func (s *FunctionDefinition) End() token.Pos { return s.Body.End() }
+
+func (s *BadStmt) Pos() token.Pos { return s.From }
+func (s *BadStmt) End() token.Pos { return s.To }
+
+func (x *BadExpr) Pos() token.Pos { return x.From }
+func (x *BadExpr) End() token.Pos { return x.To }
+
+func (x *Ident) Pos() token.Pos { return x.NamePos }
+func (x *Ident) End() token.Pos { return textEnd(x.NamePos, x.Name) }
+
+func (x *MemberAccess) Pos() token.Pos { return x.X.Pos() }
+func (x *MemberAccess) End() token.Pos { return x.Member.End() }
+
+func (x *FunctionCall) Pos() token.Pos { return x.Fun.Pos() }
+func (x *FunctionCall) End() token.Pos { return x.Rparen + 1 }
+
+func (x *Literal) Pos() token.Pos { return x.ValuePos }
+func (x *Literal) End() token.Pos { return textEnd(x.ValuePos, x.Value) }
@@ -0,0 +1,198 @@
+package yul
+
+import (
+	"fmt"
+
+	"github.com/blockchain-labs-org/solzaemon/scanner"
+	"github.com/blockchain-labs-org/solzaemon/token"
+)
+
+// Scanner is a lexical scanner of Yul code embedded in a Solidity file.
+type Scanner struct {
+	file   *token.File
+	src    []rune
+	err    scanner.ErrorHandler
+	pos    int
+	offset token.Pos
+
+	ErrorCount int // number of errors encountered
+}
+
+// NewScanner returns a scanner for src, the contents of f, starting at the
+// rune offset. Line information is added to f as src is scanned.
+func NewScanner(f *token.File, src []rune, offset int, err scanner.ErrorHandler) *Scanner {
+	if f.Size() != len(src) {
+		panic(fmt.Sprintf("file size (%d) does not match src len (%d)", f.Size(), len(src)))
+	}
+	return &Scanner{src: src, file: f, err: err, pos: offset, offset: f.Pos(offset)}
+}
+
+func (s *Scanner) error(pos token.Pos, msg string) {
+	if s.err != nil {
+		s.err(s.file.Position(pos), msg)
+	}
+	s.ErrorCount++
+}
+
+const eof = -1
+
+func (s *Scanner) lookahead(n int) rune {
+	if s.pos+n >= len(s.src) {
+		return eof
+	}
+	return s.src[s.pos+n]
+}
+
+func (s *Scanner) next() rune {
+	if s.pos >= len(s.src) {
+		return eof
+	}
+	ch := s.src[s.pos]
+	s.offset++
+	s.pos++
+	if ch == '\n' {
+		s.file.AddLine(s.pos)
+	}
+	return ch
+}
+
+// skipBlank skips white space and comments.
+func (s *Scanner) skipBlank() {
+	for {
+		switch ch := s.lookahead(0); {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			s.next()
+		case ch == '/' && s.lookahead(1) == '/':
+			for s.lookahead(0) != '\n' && s.lookahead(0) != eof {
+				s.next()
+			}
+		case ch == '/' && s.lookahead(1) == '*':
+			pos := s.offset
+			s.next()
+			s.next()
+			for !(s.lookahead(0) == '*' && s.lookahead(1) == '/') {
+				if s.next() == eof {
+					s.error(pos, "comment not terminated")
+					return
+				}
+			}
+			s.next()
+			s.next()
+		default:
+			return
+		}
+	}
+}
+
+// Scan scans the next token and returns the token position, the token, and
+// its literal string. At the end of the source, Scan returns EOF.
+func (s *Scanner) Scan() (pos token.Pos, tok Token, lit string) {
+	s.skipBlank()
+	pos = s.offset
+	start := s.pos
+	switch ch := s.lookahead(0); {
+	case ch == eof:
+		return pos, EOF, ""
+	case isLetter(ch):
+		for isLetter(s.lookahead(0)) || isDigit(s.lookahead(0)) || s.lookahead(0) == '.' {
+			s.next()
+		}
+		lit = string(s.src[start:s.pos])
+		tok = Lookup(lit)
+		if lit == "hex" && (s.lookahead(0) == '"' || s.lookahead(0) == '\'') {
+			tok = HEX_STRING
+			s.scanString(pos)
+			lit = string(s.src[start:s.pos])
+		}
+		return pos, tok, lit
+	case isDigit(ch):
+		if ch == '0' && s.lookahead(1) == 'x' {
+			s.next()
+			s.next()
+			for isHex(s.lookahead(0)) {
+				s.next()
+			}
+		} else {
+			for isDigit(s.lookahead(0)) {
+				s.next()
+			}
+		}
+		if isLetter(s.lookahead(0)) {
+			s.error(pos, "invalid number literal")
+			for isLetter(s.lookahead(0)) || isDigit(s.lookahead(0)) {
+				s.next()
+			}
+		}
+		return pos, NUMBER, string(s.src[start:s.pos])
+	case ch == '"' || ch == '\'':
+		s.scanString(pos)
+		return pos, STRING, string(s.src[start:s.pos])
+	}
+
+	ch := s.next()
+	switch ch {
+	case '{':
+		tok = LBRACE
+	case '}':
+		tok = RBRACE
+	case '(':
+		tok = LPAREN
+	case ')':
+		tok = RPAREN
+	case ',':
+		tok = COMMA
+	case ':':
+		tok = COLON
+		if s.lookahead(0) == '=' {
+			s.next()
+			tok = ASSIGN
+		}
+	case '-':
+		tok = ILLEGAL
+		if s.lookahead(0) == '>' {
+			s.next()
+			tok = ARROW
+		}
+	default:
+		tok = ILLEGAL
+	}
+	if tok == ILLEGAL {
+		s.error(pos, fmt.Sprintf("illegal character %#U", ch))
+	}
+	return pos, tok, string(s.src[start:s.pos])
+}
+
+// scanString scans a string literal starting at its opening quote. pos is
+// the position of the literal including its prefix.
+func (s *Scanner) scanString(pos token.Pos) {
+	quote := s.next()
+	for {
+		switch ch := s.lookahead(0); {
+		case ch == quote:
+			s.next()
+			return
+		case ch == '\n' || ch == eof:
+			s.error(pos, "string literal not terminated")
+			return
+		case ch == '\\':
+			s.next()
+			if s.lookahead(0) != '\n' {
+				s.next()
+			}
+		default:
+			s.next()
+		}
+	}
+}
+
+func isLetter(ch rune) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_' || ch == '$'
+}
+
+func isDigit(ch rune) bool {
+	return '0' <= ch && ch <= '9'
+}
+
+func isHex(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
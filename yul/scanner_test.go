@@ -0,0 +1,60 @@
+package yul
+
+import (
+	"testing"
+
+	"github.com/ToQoz/gopwt/assert"
+	"github.com/blockchain-labs-org/solzaemon/token"
+)
+
+func newScanner(src string, offset int, err func(pos token.Position, msg string)) *Scanner {
+	runes := []rune(src)
+	return NewScanner(token.NewFileSet().AddFile("", -1, len(runes)), runes, offset, err)
+}
+
+func TestScan(t *testing.T) {
+	s := newScanner(`x := 1; { let y.slot := add(0x2a, "a\"b") // c
+	/* d */ function f(a) -> r { leave } hex"00ff" -> : }`, len(`x := 1; `), nil)
+	expected := []struct {
+		tok Token
+		lit string
+	}{
+		{LBRACE, "{"}, {LET, "let"}, {IDENT, "y.slot"}, {ASSIGN, ":="}, {IDENT, "add"}, {LPAREN, "("},
+		{NUMBER, "0x2a"}, {COMMA, ","}, {STRING, `"a\"b"`}, {RPAREN, ")"},
+		{FUNCTION, "function"}, {IDENT, "f"}, {LPAREN, "("}, {IDENT, "a"}, {RPAREN, ")"}, {ARROW, "->"}, {IDENT, "r"},
+		{LBRACE, "{"}, {LEAVE, "leave"}, {RBRACE, "}"}, {HEX_STRING, `hex"00ff"`}, {ARROW, "->"}, {COLON, ":"},
+		{RBRACE, "}"}, {EOF, ""},
+	}
+	for _, e := range expected {
+		_, tok, lit := s.Scan()
+		assert.Require(t, tok == e.tok, e.lit)
+		assert.Require(t, lit == e.lit, e.lit)
+	}
+	assert.OK(t, s.ErrorCount == 0)
+}
+
+func TestScanPositions(t *testing.T) {
+	s := newScanner("é\n{ x }", 2, nil) // offsets count runes
+	pos, tok, _ := s.Scan()
+	assert.OK(t, tok == LBRACE && pos == token.Pos(1+2))
+	pos, tok, _ = s.Scan()
+	assert.OK(t, tok == IDENT && pos == token.Pos(1+4))
+}
+
+func TestScanErrors(t *testing.T) {
+	var msgs []string
+	var columns []int
+	s := newScanner(`a ; 1x "b`, 0, func(pos token.Position, msg string) {
+		columns = append(columns, pos.Column)
+		msgs = append(msgs, msg)
+	})
+	for {
+		if _, tok, _ := s.Scan(); tok == EOF {
+			break
+		}
+	}
+	assert.Require(t, len(msgs) == 3)
+	assert.OK(t, msgs[0] == "illegal character U+003B ';'" && columns[0] == 3)
+	assert.OK(t, msgs[1] == "invalid number literal" && columns[1] == 5)
+	assert.OK(t, msgs[2] == "string literal not terminated" && columns[2] == 8)
+}
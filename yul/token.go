@@ -0,0 +1,101 @@
+package yul
+
+import "strconv"
+
+// Token is the set of lexical tokens of Yul.
+type Token int
+
+const (
+	ILLEGAL Token = iota
+	EOF
+
+	IDENT      // mstore, x.slot
+	NUMBER     // 42, 0x2a
+	STRING     // "abc"
+	HEX_STRING // hex"00ff"
+
+	LBRACE // {
+	RBRACE // }
+	LPAREN // (
+	RPAREN // )
+	COMMA  // ,
+	ASSIGN // :=
+	ARROW  // ->
+	COLON  // :
+
+	keyword_beg
+	BREAK    // break
+	CASE     // case
+	CONTINUE // continue
+	DEFAULT  // default
+	FALSE    // false
+	FOR      // for
+	FUNCTION // function
+	IF       // if
+	LEAVE    // leave
+	LET      // let
+	SWITCH   // switch
+	TRUE     // true
+	keyword_end
+)
+
+var tokens = [...]string{
+	ILLEGAL: "ILLEGAL",
+	EOF:     "EOF",
+
+	IDENT:      "IDENT",
+	NUMBER:     "NUMBER",
+	STRING:     "STRING",
+	HEX_STRING: "HEX_STRING",
+
+	LBRACE: "{",
+	RBRACE: "}",
+	LPAREN: "(",
+	RPAREN: ")",
+	COMMA:  ",",
+	ASSIGN: ":=",
+	ARROW:  "->",
+	COLON:  ":",
+
+	BREAK:    "break",
+	CASE:     "case",
+	CONTINUE: "continue",
+	DEFAULT:  "default",
+	FALSE:    "false",
+	FOR:      "for",
+	FUNCTION: "function",
+	IF:       "if",
+	LEAVE:    "leave",
+	LET:      "let",
+	SWITCH:   "switch",
+	TRUE:     "true",
+}
+
+// String returns the source text of punctuation and keywords, and the
+// token name otherwise.
+func (tok Token) String() string {
+	if 0 <= tok && int(tok) < len(tokens) && tokens[tok] != "" {
+		return tokens[tok]
+	}
+	return "token(" + strconv.Itoa(int(tok)) + ")"
+}
+
+var keywords map[string]Token
+
+func init() {
+	keywords = make(map[string]Token)
+	for tok := keyword_beg + 1; tok < keyword_end; tok++ {
+		keywords[tokens[tok]] = tok
+	}
+}
+
+// Lookup maps an identifier to its keyword token or IDENT (if not a keyword).
+func Lookup(ident string) Token {
+	if tok, isKeyword := keywords[ident]; isKeyword {
+		return tok
+	}
+	return IDENT
+}
+
+// IsKeyword returns true for keywords.
+func (tok Token) IsKeyword() bool { return keyword_beg < tok && tok < keyword_end }